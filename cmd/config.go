@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/interactive"
+)
+
+// runConfig adds, edits or removes Host blocks in ~/.ssh/config
+func runConfig(inv command.Invocation) int {
+	alias := ""
+	if len(inv.Args) > 0 {
+		alias = inv.Args[0]
+	}
+
+	switch inv.Action {
+	case command.AddConfig:
+		c := config.SSHConfig{
+			Name: alias,
+			Host: inv.String("hostname"),
+			User: inv.String("user"),
+			Port: inv.String("port"),
+			Key:  inv.String("key"),
+		}
+
+		if c.Host == "" {
+			if interactive.HostForm(c, "", inv.Bool("include")) {
+				fmt.Println("Host saved.")
+			}
+			return 0
+		}
+
+		if err := config.AddHost(c, inv.Bool("include")); err != nil {
+			fmt.Printf("Error adding host: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Added host '%s'.\n", alias)
+		return 0
+
+	case command.EditConfig:
+		c, err := config.GetConfig(alias)
+		if err != nil || c.Name == "" {
+			fmt.Printf("Host '%s' not found in ~/.ssh/config.\n", alias)
+			return 1
+		}
+
+		changed := false
+		for flag, value := range map[string]*string{"hostname": &c.Host, "user": &c.User, "port": &c.Port, "key": &c.Key} {
+			if inv.IsSet(flag) {
+				*value = inv.String(flag)
+				changed = true
+			}
+		}
+
+		if !changed {
+			if interactive.HostForm(c, alias, false) {
+				fmt.Println("Host saved.")
+			}
+			return 0
+		}
+
+		if err := config.UpdateHost(alias, c); err != nil {
+			fmt.Printf("Error editing host: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Updated host '%s'.\n", alias)
+		return 0
+
+	case command.RemoveConfig:
+		if err := config.RemoveHost(alias); err != nil {
+			fmt.Printf("Error removing host: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Removed host '%s'.\n", alias)
+		return 0
+	}
+
+	return 2
+}
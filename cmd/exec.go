@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MrLonely14/ggh/internal/batch"
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/meta"
+)
+
+// runExec runs a command over ssh on every host matching the query
+func runExec(inv command.Invocation) int {
+	query := inv.String("q")
+
+	configs, err := config.Parse(config.GetConfigFile())
+	if err != nil {
+		fmt.Printf("Error reading ssh config: %v\n", err)
+		return 1
+	}
+	historyList, err := history.FetchWithDefaultFile()
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	store, err := meta.Load()
+	if err != nil {
+		fmt.Printf("Error reading host metadata: %v\n", err)
+		return 1
+	}
+
+	matched, err := batch.Match(query, batch.Candidates(configs, historyList), store)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	if len(matched) == 0 {
+		fmt.Printf("No hosts match %q.\n", query)
+		return 1
+	}
+
+	hosts := make([]batch.Host, 0, len(matched))
+	for _, c := range matched {
+		hosts = append(hosts, batch.HostFromConfig(c))
+	}
+
+	results := batch.Run(context.Background(), hosts, inv.Args, batch.Options{
+		Concurrency: inv.Int("c"),
+		Timeout:     inv.Duration("timeout"),
+		Grouped:     inv.Bool("group"),
+		// Never stop to ask for a password or a host key confirmation
+		SSHArgs: []string{"-o", "BatchMode=yes"},
+		Stdout:  os.Stdout,
+		IKnow:   inv.IKnow,
+	})
+
+	fmt.Println()
+	fmt.Println(batch.Summary(results))
+
+	for _, r := range results {
+		if r.Err != nil || r.ExitCode != 0 {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/batch"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/interactive"
+	"github.com/MrLonely14/ggh/internal/ssh"
+)
+
+// resolveFuzzy handles "ggh NAME" when NAME is neither an alias nor a known
+// host. A single strong match is connected to, several open the selector.
+// It returns false when ssh should get the arguments as they are, also
+// when NAME only matches loosely.
+func resolveFuzzy(args []string) ([]string, bool) {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") || strings.Contains(args[0], "@") {
+		return nil, false
+	}
+	query := args[0]
+
+	configs, err := config.Parse(config.GetConfigFile())
+	if err != nil {
+		return nil, false
+	}
+	historyList, err := history.FetchWithDefaultFile()
+	if err != nil {
+		return nil, false
+	}
+
+	candidates := batch.Candidates(configs, historyList)
+	for _, c := range candidates {
+		if c.Name == query || (c.IsDirectSSH() && c.Host == query) {
+			return nil, false
+		}
+	}
+
+	matches, unique := config.Find(query, candidates)
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	if !unique {
+		return interactive.Search(candidates, query), true
+	}
+
+	c := matches[0]
+	history.AddHistory(c)
+	if c.IsDirectSSH() {
+		fmt.Fprintf(os.Stderr, "ggh: %q matched %s\n", query, c.Host)
+		return ssh.GenerateCommandArgs(c), true
+	}
+	fmt.Fprintf(os.Stderr, "ggh: %q matched %s\n", query, c.Name)
+	return []string{c.Name}, true
+}
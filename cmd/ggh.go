@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/completion"
 	"github.com/MrLonely14/ggh/internal/config"
 	"github.com/MrLonely14/ggh/internal/history"
 	"github.com/MrLonely14/ggh/internal/interactive"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/settings"
 	"github.com/MrLonely14/ggh/internal/ssh"
 	"github.com/MrLonely14/ggh/internal/theme"
 	"github.com/MrLonely14/ggh/internal/tunnel"
@@ -14,26 +18,113 @@ import (
 )
 
 func Main(version string) {
-	command.CheckSSH()
+	inv, err := command.Parse(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		var usageErr *command.UsageError
+		if errors.As(err, &usageErr) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
 
-	args := os.Args[1:]
+	if err := settings.Get().Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v, using the default settings\n", err)
+	}
+	if err := theme.Load(settings.Get()); err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v, using the auto theme\n", err)
+	}
+	warnings, err := interactive.LoadKeys(settings.Get().Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v, using the default keys\n", err)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "ggh: %s\n", w)
+	}
+	if err := interactive.LoadColumns(settings.Get().Tables); err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v, using the default columns\n", err)
+	}
 
-	action, value := command.Which()
-	switch action {
+	switch inv.Action {
+	case command.ShowHelp:
+		fmt.Print(inv.Command.Help())
+		return
 	case command.ShowVersion:
 		fmt.Printf("ggh version %s\n", version)
 		return
+	case command.Completion:
+		for _, c := range completion.Complete(inv.Args, completion.DefaultSource) {
+			fmt.Println(c)
+		}
+		return
+	case command.CompletionScript:
+		script, err := completion.Script(inv.Args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Print(script)
+		return
+	}
+
+	switch inv.Action {
+	case command.ListRecordings:
+		os.Exit(printRecordings(outputFormat(inv)))
+	case command.PlayRecording:
+		os.Exit(playRecording(inv))
+	case command.ListMux:
+		os.Exit(printMasters(outputFormat(inv)))
+	case command.ListKnownHosts:
+		os.Exit(printKnownHosts(outputFormat(inv), inv.Bool("stale")))
+	case command.ListAgent:
+		os.Exit(printAgent(outputFormat(inv)))
+	case command.ListKeys:
+		os.Exit(printKeys(outputFormat(inv)))
+	case command.InteractiveKnownHosts:
+		interactive.KnownHosts()
+		return
+	}
+
+	command.CheckSSH()
+
+	args := inv.Args
+	opts := ssh.Options{IKnow: inv.IKnow}
+
+	if inv.Action == command.RecordSession {
+		opts.Record = true
+		if len(args) > 0 {
+			inv.Action = command.PassThroughExact
+		} else {
+			inv.Action = command.InteractiveHistory
+		}
+	}
+
+	switch inv.Action {
 	case command.InteractiveHistory:
-		args = interactive.History()
+		var reapplied []tunnel.Tunnel
+		args, reapplied = interactive.History(true, nil)
+		if len(reapplied) > 0 {
+			args = prependTunnelArgs(reapplied, args)
+		}
 	case command.InteractiveConfig:
-		args = interactive.Config("")
-	case command.InteractiveConfigWithSearch:
-		args = interactive.Config(value)
+		search := ""
+		if len(inv.Args) > 0 {
+			search = inv.Args[0]
+		}
+		args = interactive.Config(search)
+	case command.Exec:
+		os.Exit(runExec(inv))
+	case command.StopMux:
+		os.Exit(stopMasters(inv.Args[0]))
+	case command.AddConfig, command.EditConfig, command.RemoveConfig:
+		os.Exit(runConfig(inv))
+	case command.HistoryDoctor:
+		os.Exit(runHistoryDoctor(inv))
 	case command.ListHistory:
-		history.Print()
+		history.Print(outputFormat(inv))
 		return
 	case command.ListConfig:
-		config.Print()
+		config.Print(outputFormat(inv))
 		return
 	case command.InteractiveTunnels:
 		// Interactive tunnel management (create/edit/delete/select)
@@ -41,32 +132,81 @@ func Main(version string) {
 		return
 	case command.ListTunnels:
 		// List all tunnels in a table
-		printTunnels()
+		printTunnels(outputFormat(inv))
 		return
 	case command.SelectTunnels:
-		// Select tunnels and apply to next SSH connection
-		selectedTunnels := interactive.SelectTunnels(true)
-		if len(selectedTunnels) == 0 {
-			return
+		var selectedTunnels []tunnel.Tunnel
+		if len(inv.Args) == 0 {
+			// Select tunnels and apply to next SSH connection
+			selectedTunnels = interactive.SelectTunnels(true)
+			if len(selectedTunnels) == 0 {
+				return
+			}
+		} else {
+			// Apply the named tunnels without the tunnel selector
+			for _, name := range inv.Args {
+				t, err := tunnel.FetchByName(name)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				selectedTunnels = append(selectedTunnels, *t)
+			}
 		}
-		// Now select SSH connection
-		args = interactive.History()
+		// Now select SSH connection, remembering the tunnels with it so it
+		// can be replayed
+		args, _ = interactive.History(false, selectedTunnels)
 		// Prepend tunnel args to SSH args
 		args = prependTunnelArgs(selectedTunnels, args)
-	default:
+	case command.Last:
+		args = replayLast(inv)
+	case command.Route:
+		var target config.SSHConfig
+		if len(inv.Args) > 0 {
+			target = hostConfig(inv.Args[0])
+		}
+		args = interactive.Route(target)
+	case command.RunTool:
+		os.Exit(runTool(inv))
+	case command.PassThroughExact:
 		history.AddHistoryFromArgs(args)
+	default:
+		if resolved, ok := resolveFuzzy(args); ok {
+			args = resolved
+		} else {
+			history.AddHistoryFromArgs(args)
+		}
+	}
+	os.Exit(ssh.RunWith(args, opts))
+}
+
+// outputFormat returns the format asked for with --output, exiting on
+// unknown formats
+func outputFormat(inv command.Invocation) output.Format {
+	format, err := output.ParseFormat(inv.String("output"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, &command.UsageError{Command: inv.Command, Message: err.Error()})
+		os.Exit(2)
 	}
-	ssh.Run(args)
+	return format
 }
 
 // printTunnels displays all tunnels in a formatted table
-func printTunnels() {
+func printTunnels(format output.Format) {
 	tunnels, err := tunnel.FetchAll()
 	if err != nil {
 		fmt.Printf("Error loading tunnels: %v\n", err)
 		os.Exit(1)
 	}
 
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, tunnel.RecordKeys, tunnel.Records(tunnels)); err != nil {
+			fmt.Printf("Error printing tunnels: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(tunnels) == 0 {
 		fmt.Println("No tunnels configured. Use 'ggh tunnels' to create one.")
 		return
@@ -106,11 +246,7 @@ func prependTunnelArgs(tunnels []tunnel.Tunnel, args []string) []string {
 	}
 
 	// Update last used for all selected tunnels
-	ids := make([]string, len(tunnels))
-	for i, t := range tunnels {
-		ids[i] = t.ID
-	}
-	_ = tunnel.UpdateLastUsedBatch(ids)
+	_ = tunnel.UpdateLastUsedBatch(tunnel.IDs(tunnels))
 
 	// Print tunnel summary
 	fmt.Println(tunnel.FormatTunnelsSummary(tunnels))
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/interactive"
+)
+
+// runHistoryDoctor finds history entries whose alias vanished from the ssh
+// config and re-links, converts or deletes them
+func runHistoryDoctor(inv command.Invocation) int {
+	orphans, err := history.Doctor()
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned history entries.")
+		return 0
+	}
+
+	var repairs []history.Repair
+	switch {
+	case inv.Bool("relink"):
+		for _, o := range orphans {
+			if o.Suggestion.Name != "" {
+				repairs = append(repairs, history.Repair{Alias: o.Entry.Connection.Name, Action: history.RepairRelink, Target: o.Suggestion.Name})
+			}
+		}
+	case inv.Bool("direct"):
+		for _, o := range orphans {
+			repairs = append(repairs, history.Repair{Alias: o.Entry.Connection.Name, Action: history.RepairDirect})
+		}
+	case inv.Bool("delete"):
+		for _, o := range orphans {
+			repairs = append(repairs, history.Repair{Alias: o.Entry.Connection.Name, Action: history.RepairDelete})
+		}
+	default:
+		repairs = interactive.Doctor(orphans)
+	}
+
+	if len(repairs) == 0 {
+		fmt.Println("Nothing to repair.")
+		return 0
+	}
+
+	if err := history.ApplyRepairs(repairs); err != nil {
+		fmt.Printf("Error saving history: %v\n", err)
+		return 1
+	}
+
+	for _, r := range repairs {
+		fmt.Println(r.Describe())
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/agent"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/keys"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// printKeys lists the ssh keys with the hosts using them, and warns about
+// the ones ssh can't use
+func printKeys(format output.Format) int {
+	list, err := keys.Inventory(history.Hosts())
+	if err != nil {
+		fmt.Printf("Error reading keys: %v\n", err)
+		return 1
+	}
+
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, keys.RecordKeys, keys.Records(list)); err != nil {
+			fmt.Printf("Error printing keys: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No keys found in ~/.ssh.")
+		return 0
+	}
+
+	var warnings []string
+	rows := make([]table.Row, 0, len(list))
+	for _, k := range list {
+		bits, mode := "", ""
+		if k.Bits > 0 {
+			bits = fmt.Sprintf("%d", k.Bits)
+		}
+		if !k.Missing {
+			mode = fmt.Sprintf("%04o", k.Mode.Perm())
+		}
+		rows = append(rows, table.Row{
+			shortPath(k.Path),
+			k.Type,
+			bits,
+			k.Fingerprint,
+			k.Passphrase,
+			mode,
+			strings.Join(k.Hosts, ", "),
+		})
+		warnings = append(warnings, k.Warnings()...)
+	}
+	fmt.Println(theme.PrintTable(rows, theme.KeysTable))
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
+	return 0
+}
+
+// printAgent lists the keys loaded in ssh-agent, with the key files and
+// hosts they belong to
+func printAgent(format output.Format) int {
+	ids, err := agent.Identities(agent.Socket())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 1
+	}
+	list, err := keys.Inventory(history.Hosts())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+	}
+	agent.Attach(ids, list)
+
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, agent.RecordKeys, agent.Records(ids)); err != nil {
+			fmt.Printf("Error printing agent keys: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(ids) == 0 {
+		fmt.Println("ssh-agent has no keys. ssh-add adds one, or enable agent in the settings to be asked when connecting.")
+		return 0
+	}
+
+	rows := make([]table.Row, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, table.Row{
+			id.Type,
+			id.Fingerprint,
+			id.Comment,
+			shortPath(id.Path),
+			strings.Join(id.Hosts, ", "),
+		})
+	}
+	fmt.Println(theme.PrintTable(rows, theme.AgentTable))
+	return 0
+}
+
+// shortPath writes paths under the home directory with ~
+func shortPath(path string) string {
+	home := config.HomeDir()
+	if rel, err := filepath.Rel(home, path); err == nil && home != "" && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
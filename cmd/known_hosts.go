@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/knownhosts"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// printKnownHosts lists the known_hosts entries with the hosts using them,
+// only the stale ones with staleOnly
+func printKnownHosts(format output.Format, staleOnly bool) int {
+	entries, err := knownhosts.Load(knownhosts.Path())
+	if err != nil {
+		fmt.Printf("Error reading known hosts: %v\n", err)
+		return 1
+	}
+
+	usages := knownhosts.CrossReference(entries, history.Hosts())
+	if staleOnly {
+		var stale []knownhosts.Usage
+		for _, u := range usages {
+			if u.Stale() {
+				stale = append(stale, u)
+			}
+		}
+		usages = stale
+	}
+
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, knownhosts.RecordKeys, knownhosts.Records(usages)); err != nil {
+			fmt.Printf("Error printing known hosts: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(usages) == 0 {
+		fmt.Println("No known hosts found.")
+		return 0
+	}
+
+	rows := make([]table.Row, 0, len(usages))
+	for _, u := range usages {
+		rows = append(rows, table.Row{
+			fmt.Sprintf("%d", u.Line),
+			u.Name(),
+			u.KeyType,
+			u.Fingerprint(),
+			u.UsedByColumn(),
+		})
+	}
+	fmt.Println(theme.PrintTable(rows, theme.KnownHostsTable))
+	return 0
+}
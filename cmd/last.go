@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/tunnel"
+)
+
+// replayLast returns the ssh arguments of the Nth most recent history entry,
+// with the tunnels that were applied to it, and records the connection again
+func replayLast(inv command.Invocation) []string {
+	n := 1
+	if len(inv.Args) > 0 {
+		var err error
+		n, err = strconv.Atoi(inv.Args[0])
+		if err != nil || n < 1 {
+			fmt.Fprintln(os.Stderr, &command.UsageError{Command: inv.Command, Message: fmt.Sprintf("N must be a positive number, got %q", inv.Args[0])})
+			os.Exit(2)
+		}
+	}
+
+	entry, err := history.Last(n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		os.Exit(1)
+	}
+
+	if strings.HasPrefix(entry.Connection.Name, config.MissingConfig) {
+		fmt.Fprintf(os.Stderr, "ggh: %s is no longer in your ssh config, run 'ggh history doctor' to repair it\n", entry.Connection.Name)
+		os.Exit(1)
+	}
+
+	args := entry.SSHArgs()
+	fmt.Fprintf(os.Stderr, "Reconnecting to %s\n", strings.Join(args, " "))
+
+	all, err := tunnel.LoadTunnels()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+	}
+	tunnels, deleted := entry.SavedTunnels(all)
+	if deleted > 0 {
+		fmt.Fprintf(os.Stderr, "ggh: %d tunnel(s) of this connection were deleted, connecting without them\n", deleted)
+	}
+
+	entry.Connection.CleanName()
+	entry.Tunnels = tunnel.IDs(tunnels)
+	history.AddEntry(entry)
+
+	if len(tunnels) == 0 {
+		return args
+	}
+	return prependTunnelArgs(tunnels, args)
+}
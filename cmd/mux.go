@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/ssh"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// printMasters lists the master connections of the multiplexed hosts
+func printMasters(format output.Format) int {
+	masters, err := ssh.Masters()
+	if err != nil {
+		fmt.Printf("Error reading master connections: %v\n", err)
+		return 1
+	}
+
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, ssh.MasterKeys, ssh.MasterRecords(masters)); err != nil {
+			fmt.Printf("Error printing master connections: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(masters) == 0 {
+		fmt.Println("No master connections. List the hosts to share connections with in the multiplex settings.")
+		return 0
+	}
+
+	rows := make([]table.Row, 0, len(masters))
+	for _, m := range masters {
+		status := "live"
+		if !m.Live {
+			status = "stale"
+		}
+		rows = append(rows, table.Row{
+			m.Host,
+			m.User,
+			m.Port,
+			time.Since(m.Since).Round(time.Second).String(),
+			status,
+		})
+	}
+	fmt.Println(theme.PrintTable(rows, theme.MuxTable))
+	return 0
+}
+
+// stopMasters closes the master connections to host
+func stopMasters(host string) int {
+	stopped, err := ssh.StopMasters(host, settings.Get().Transport)
+	for _, m := range stopped {
+		fmt.Fprintf(os.Stderr, "Stopped the master connection to %s@%s:%s\n", m.User, m.Host, m.Port)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 1
+	}
+	return 0
+}
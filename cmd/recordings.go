@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/record"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// printRecordings lists the recorded sessions
+func printRecordings(format output.Format) int {
+	list, err := record.List()
+	if err != nil {
+		fmt.Printf("Error reading recordings: %v\n", err)
+		return 1
+	}
+
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, record.RecordKeys, record.Records(list)); err != nil {
+			fmt.Printf("Error printing recordings: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No recordings. Use 'ggh --record HOST' to record a session.")
+		return 0
+	}
+
+	rows := make([]table.Row, 0, len(list))
+	for _, r := range list {
+		rows = append(rows, table.Row{
+			r.Name,
+			r.Host,
+			r.Start.Format("2006-01-02 15:04:05"),
+			r.Duration.Round(time.Second).String(),
+			fmt.Sprintf("%d KB", (r.Size+1023)/1024),
+		})
+	}
+	fmt.Println(theme.PrintTable(rows, theme.RecordingTable))
+	return 0
+}
+
+// playRecording replays a recorded session in the terminal
+func playRecording(inv command.Invocation) int {
+	path, err := record.Find(inv.Args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 1
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	_, events, err := record.Read(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %s: %v\n", path, err)
+		if len(events) == 0 {
+			return 1
+		}
+	}
+
+	opts := record.PlayOptions{Speed: inv.Float("speed"), MaxIdle: inv.Duration("idle")}
+	if err := record.Play(os.Stdout, events, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 1
+	}
+	return 0
+}
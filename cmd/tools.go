@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/interactive"
+	"github.com/MrLonely14/ggh/internal/ssh"
+)
+
+// runTool runs mosh, sftp, scp or rsync against a host given on the command
+// line or picked in the selector, and returns the tool's exit code
+func runTool(inv command.Invocation) int {
+	tool := ssh.Tool(inv.Command.Name)
+	if _, err := ssh.CheckTool(tool); err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 1
+	}
+
+	var endpoint ssh.Endpoint
+	args := inv.Args
+	switch tool {
+	case ssh.Mosh, ssh.SFTP:
+		endpoint.Jump = inv.String("J")
+		switch {
+		case len(inv.Args) == 1:
+			endpoint.SSHConfig = hostConfig(inv.Args[0])
+			history.AddHistoryFromArgs(inv.Args)
+		case inv.Bool("config"):
+			endpoint.SSHConfig = interactive.PickConfig("")
+		default:
+			endpoint.SSHConfig = interactive.PickHistory()
+		}
+		args = nil
+	case ssh.SCP, ssh.Rsync:
+		// without a :PATH the arguments already name their hosts
+		if ssh.HasRemotePath(args) {
+			endpoint.SSHConfig = interactive.PickHistory()
+		}
+	}
+
+	code, err := ssh.RunTool(tool, endpoint, args, ssh.Options{IKnow: inv.IKnow})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+	}
+	return code
+}
+
+// hostConfig returns the config of an alias, or the user and host of a
+// user@host argument. Other names are left for ssh to resolve.
+func hostConfig(host string) config.SSHConfig {
+	if !strings.Contains(host, "@") {
+		if c, err := config.GetConfig(host); err == nil && c.Name != "" {
+			return c
+		}
+		return config.SSHConfig{Name: host, Host: host}
+	}
+
+	user, hostname, _ := strings.Cut(host, "@")
+	return config.SSHConfig{User: user, Host: hostname}
+}
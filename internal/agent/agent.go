@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/keys"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/settings"
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// Identity is a key loaded in ssh-agent
+type Identity struct {
+	Type        string
+	Fingerprint string
+	Comment     string
+	// Path and Hosts are set by Attach when the key file is known
+	Path  string
+	Hosts []string
+}
+
+// Socket returns the agent socket from SSH_AUTH_SOCK, empty when no agent
+// is running
+func Socket() string {
+	return os.Getenv("SSH_AUTH_SOCK")
+}
+
+// Identities lists the keys loaded in the agent listening on socket
+func Identities(socket string) ([]Identity, error) {
+	if socket == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK isn't set, is ssh-agent running?")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	list, err := sshagent.NewClient(conn).List()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]Identity, 0, len(list))
+	for _, k := range list {
+		ids = append(ids, Identity{Type: k.Format, Fingerprint: keys.Fingerprint(k.Blob), Comment: k.Comment})
+	}
+	return ids, nil
+}
+
+// Loaded reports whether one of ids is k
+func Loaded(ids []Identity, k keys.Key) bool {
+	for _, id := range ids {
+		if id.Fingerprint == k.Fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// Add runs ssh-add on the terminal, so it can ask for the passphrase. A
+// lifetime limits how long the key stays loaded.
+func Add(path, lifetime string) error {
+	args := []string{path}
+	if lifetime != "" {
+		args = []string{"-t", lifetime, path}
+	}
+	cmd := exec.Command("ssh-add", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Ensure offers to add the key at path to the agent when it isn't loaded,
+// asking on in and out. in is shared with the other prompts, a reader of its
+// own would buffer their answers. Nothing is asked when the key or the agent
+// can't be read, ssh then deals with the key itself.
+func Ensure(path string, s settings.Agent, in *bufio.Reader, out io.Writer) error {
+	if path == "" {
+		return nil
+	}
+	k := keys.Inspect(path)
+	if k.Missing {
+		return nil
+	}
+	// encrypted PEM keys only have their public key in the .pub file
+	if k.Fingerprint == "" {
+		fmt.Fprintf(out, "ggh: can't tell whether %s is loaded in ssh-agent, it has no .pub file\n", path)
+		return nil
+	}
+
+	ids, err := Identities(Socket())
+	if err != nil {
+		fmt.Fprintf(out, "ggh: can't list the ssh-agent keys: %v\n", err)
+		return nil
+	}
+	if Loaded(ids, k) {
+		return nil
+	}
+
+	question := fmt.Sprintf("ggh: %s isn't loaded in ssh-agent, add it", path)
+	if s.Lifetime != "" {
+		question += " for " + s.Lifetime
+	}
+	fmt.Fprint(out, question+"? [Y/n] ")
+
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return Add(k.Path, s.Lifetime)
+	}
+	return nil
+}
+
+// Attach sets the path and hosts of the identities from the keys of list
+// with the same fingerprint
+func Attach(ids []Identity, list []keys.Key) {
+	for i := range ids {
+		for _, k := range list {
+			if k.Fingerprint == ids[i].Fingerprint {
+				ids[i].Path, ids[i].Hosts = k.Path, k.Hosts
+				break
+			}
+		}
+	}
+}
+
+// RecordKeys are the fields of an agent identity in machine readable output
+var RecordKeys = []string{"type", "fingerprint", "comment", "path", "hosts"}
+
+// Records converts identities to output records
+func Records(ids []Identity) []output.Record {
+	records := make([]output.Record, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, output.Record{
+			{Key: "type", Value: id.Type},
+			{Key: "fingerprint", Value: id.Fingerprint},
+			{Key: "comment", Value: id.Comment},
+			{Key: "path", Value: id.Path},
+			{Key: "hosts", Value: strings.Join(id.Hosts, ",")},
+		})
+	}
+	return records
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/keys"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"golang.org/x/crypto/ssh"
+	sshagent "golang.org/x/crypto/ssh/agent"
+)
+
+// serveAgent runs an in-process agent on a socket in a temp dir and points
+// SSH_AUTH_SOCK at it
+func serveAgent(t *testing.T) sshagent.Agent {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	keyring := sshagent.NewKeyring()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = sshagent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socket)
+	return keyring
+}
+
+// writeKey writes an ed25519 key pair to dir and returns its path and key
+func writeKey(t *testing.T, dir, name string) (string, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".pub", ssh.MarshalAuthorizedKey(sshPub), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, priv
+}
+
+func TestIdentities(t *testing.T) {
+	if _, err := Identities(""); err == nil {
+		t.Error("Identities without a socket succeeded")
+	}
+
+	keyring := serveAgent(t)
+	dir := t.TempDir()
+	path, priv := writeKey(t, dir, "loaded")
+	if err := keyring.Add(sshagent.AddedKey{PrivateKey: priv, Comment: "me@laptop"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := Identities(Socket())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0].Type != "ssh-ed25519" || ids[0].Comment != "me@laptop" {
+		t.Fatalf("Identities() = %+v", ids)
+	}
+
+	k := keys.Inspect(path)
+	if !Loaded(ids, k) {
+		t.Errorf("Loaded() = false for the key in the agent, fingerprints %s and %s", ids[0].Fingerprint, k.Fingerprint)
+	}
+	other, _ := writeKey(t, dir, "other")
+	if Loaded(ids, keys.Inspect(other)) {
+		t.Error("Loaded() = true for a key not in the agent")
+	}
+
+	k.Hosts = []string{"web"}
+	Attach(ids, []keys.Key{k})
+	if ids[0].Path != path || strings.Join(ids[0].Hosts, ",") != "web" {
+		t.Errorf("Attach() gave %+v", ids[0])
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	keyring := serveAgent(t)
+	dir := t.TempDir()
+	loaded, priv := writeKey(t, dir, "loaded")
+	if err := keyring.Add(sshagent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatal(err)
+	}
+	missing, _ := writeKey(t, dir, "missing")
+	// an encrypted PEM key without its .pub has no fingerprint to look up
+	encrypted := filepath.Join(dir, "encrypted")
+	block := &pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("secret")}
+	if err := os.WriteFile(encrypted, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the fake ssh-add records its arguments
+	bin := t.TempDir()
+	log := filepath.Join(bin, "log")
+	script := "#!/bin/sh\necho \"$*\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh-add"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	s := settings.Agent{Enabled: true, Lifetime: "1h"}
+	tests := []struct {
+		name   string
+		path   string
+		answer string
+		asked  bool
+		added  string
+		says   string
+	}{
+		{"loaded", loaded, "", false, "", ""},
+		{"no key", "", "", false, "", ""},
+		{"refused", missing, "n\n", true, "", ""},
+		{"accepted", missing, "\n", true, "-t 1h " + missing + "\n", ""},
+		{"encrypted without .pub", encrypted, "\n", false, "", "can't tell whether " + encrypted + " is loaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(log)
+			var out bytes.Buffer
+			if err := Ensure(tt.path, s, bufio.NewReader(strings.NewReader(tt.answer)), &out); err != nil {
+				t.Fatal(err)
+			}
+			if asked := strings.Contains(out.String(), "for 1h? [Y/n]"); asked != tt.asked {
+				t.Errorf("asked = %v, output %q", asked, out.String())
+			}
+			if !strings.Contains(out.String(), tt.says) {
+				t.Errorf("output %q, want %q", out.String(), tt.says)
+			}
+			added, _ := os.ReadFile(log)
+			if string(added) != tt.added {
+				t.Errorf("ssh-add ran with %q, want %q", added, tt.added)
+			}
+		})
+	}
+}
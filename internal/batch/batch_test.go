@@ -0,0 +1,196 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+// fakeSSH puts an ssh script in PATH that echoes the host argument and
+// fails or hangs for hosts named "broken" and "slow"
+func fakeSSH(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+while [ "$1" = "-o" ]; do shift 2; done
+host="$1"
+shift
+case "$host" in
+  broken) echo "boom" >&2; exit 3 ;;
+  slow) sleep 5 ;;
+esac
+echo "$host: $*"
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+var hosts = []config.SSHConfig{
+	{Name: "web-1", Host: "10.0.0.1", User: "deploy"},
+	{Name: "web-2", Host: "10.0.0.2", User: "deploy", Port: "2222"},
+	{Name: "db-1", Host: "10.0.1.1", User: "postgres"},
+}
+
+var store = meta.Store{
+	"web-1": {Tags: []string{"env:prod", "team:web"}},
+	"web-2": {Tags: []string{"env:staging", "team:web"}},
+	"db-1":  {Tags: []string{"env:prod"}, Note: "primary, failover in eu-west"},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"web", []string{"web-1", "web-2"}},
+		{"name:web-*", []string{"web-1", "web-2"}},
+		{"host:10.0.1.*", []string{"db-1"}},
+		{"user:deploy port:2222", []string{"web-2"}},
+		{"WEB-1", []string{"web-1"}},
+		{"nothing", []string{}},
+		{"tag:env:prod", []string{"web-1", "db-1"}},
+		{"tag:team:* tag:env:prod", []string{"web-1"}},
+		{"note:failover", []string{"db-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := Match(tt.query, hosts, store)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			names := make([]string, 0, len(got))
+			for _, c := range got {
+				names = append(names, c.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Match(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+		})
+	}
+
+	if _, err := Match("color:blue", hosts, store); err == nil {
+		t.Errorf("Match() with unknown field should fail")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	historyList := []history.SSHHistory{
+		{Connection: config.SSHConfig{Name: "web-1", Host: "10.0.0.1"}},
+		{Connection: config.SSHConfig{Name: config.DirectSSH, Host: "192.168.1.5", User: "root"}},
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "old", Host: "10.9.9.9"}},
+	}
+
+	got := Candidates(hosts, historyList)
+	if len(got) != 4 {
+		t.Fatalf("Candidates() returned %d hosts, want 4", len(got))
+	}
+	if got[3].Host != "192.168.1.5" || !got[3].IsDirectSSH() || got[3].Name != "" {
+		t.Errorf("Candidates() direct entry = %+v", got[3])
+	}
+}
+
+func TestRunPrefixed(t *testing.T) {
+	fakeSSH(t)
+
+	var out bytes.Buffer
+	results := Run(context.Background(), []Host{
+		{Name: "web-1", Args: []string{"web-1"}},
+		{Name: "broken", Args: []string{"broken"}},
+	}, []string{"uptime"}, Options{Concurrency: 2, SSHArgs: []string{"-o", "BatchMode=yes"}, Stdout: &out})
+
+	if results[0].ExitCode != 0 || results[0].Err != nil {
+		t.Errorf("web-1 result = %+v", results[0])
+	}
+	if results[1].ExitCode != 3 {
+		t.Errorf("broken exit code = %d, want 3", results[1].ExitCode)
+	}
+	if !strings.Contains(out.String(), "[web-1 ] web-1: uptime\n") {
+		t.Errorf("missing prefixed output, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "[broken] boom\n") {
+		t.Errorf("missing prefixed stderr, got:\n%s", out.String())
+	}
+}
+
+func TestRunGrouped(t *testing.T) {
+	fakeSSH(t)
+
+	var out bytes.Buffer
+	Run(context.Background(), []Host{
+		{Name: "10.0.0.1", Args: []string{"root@10.0.0.1", "", "-p", "22"}},
+	}, []string{"echo", "hi"}, Options{Grouped: true, Stdout: &out})
+
+	want := "── 10.0.0.1 (exit 0) ──\nroot@10.0.0.1: -p 22 echo hi\n"
+	if out.String() != want {
+		t.Errorf("grouped output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	fakeSSH(t)
+
+	var out bytes.Buffer
+	start := time.Now()
+	results := Run(context.Background(), []Host{
+		{Name: "slow", Args: []string{"slow"}},
+		{Name: "web-2", Args: []string{"web-2"}},
+	}, []string{"uptime"}, Options{Concurrency: 1, Timeout: 200 * time.Millisecond, Stdout: &out})
+
+	if !results[0].TimedOut() {
+		t.Errorf("slow host should time out, got %+v", results[0])
+	}
+	if results[1].ExitCode != 0 {
+		t.Errorf("web-2 should still run after a timeout, got %+v", results[1])
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Errorf("timeout did not stop the slow host")
+	}
+
+	summary := Summary(results)
+	if !strings.Contains(summary, "timeout") || !strings.Contains(summary, "ok") {
+		t.Errorf("summary is missing statuses:\n%s", summary)
+	}
+}
+
+func TestRunGuarded(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeSSH(t)
+	s := settings.Settings{Guards: []settings.Guard{{Match: []string{"prod-*"}}}}
+	hosts := []Host{
+		{Name: "prod-db", Args: []string{"prod-db"}},
+		{Name: "web-1", Args: []string{"web-1"}},
+	}
+
+	// the tests have no terminal to confirm on
+	var out bytes.Buffer
+	results := run(context.Background(), hosts, []string{"uptime"}, Options{Stdout: &out}, s)
+	if results[0].Err == nil || strings.Contains(out.String(), "prod-db: uptime") {
+		t.Errorf("prod-db ran without confirmation: %+v\n%s", results[0], out.String())
+	}
+	if results[1].Err != nil || !strings.Contains(out.String(), "web-1: uptime") {
+		t.Errorf("web-1 result = %+v\n%s", results[1], out.String())
+	}
+
+	out.Reset()
+	results = run(context.Background(), hosts, []string{"uptime"}, Options{Stdout: &out, IKnow: true}, s)
+	if results[0].Err != nil || !strings.Contains(out.String(), "prod-db: uptime") {
+		t.Errorf("prod-db with IKnow = %+v\n%s", results[0], out.String())
+	}
+
+	results = run(context.Background(), hosts, []string{"uptime"}, Options{Stdout: &out}, settings.Parse([]byte("{")))
+	if results[0].Err == nil || results[1].Err == nil {
+		t.Errorf("hosts ran with unparsed settings: %+v", results)
+	}
+}
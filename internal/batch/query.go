@@ -0,0 +1,121 @@
+package batch
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/meta"
+)
+
+// Match returns the configs matching every term of the query.
+// Terms are separated by spaces and are either a bare pattern, matched
+// against the name and host, or a field:pattern pair where field is one
+// of name, host, user, port, tag or note. tag matches any tag of the host
+// in store. Patterns containing *, ? or [ are globs, anything else is a
+// case-insensitive substring.
+func Match(query string, list []config.SSHConfig, store meta.Store) ([]config.SSHConfig, error) {
+	terms := strings.Fields(query)
+
+	for _, term := range terms {
+		if field, _, ok := strings.Cut(term, ":"); ok && !isField(field) {
+			return nil, fmt.Errorf("unknown query field %q (supported: name, host, user, port, tag, note)", field)
+		}
+	}
+
+	result := make([]config.SSHConfig, 0)
+	for _, c := range list {
+		matched := true
+		for _, term := range terms {
+			if !matchTerm(term, c, store.Get(c.MetaKey())) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, c)
+		}
+	}
+
+	return result, nil
+}
+
+func isField(field string) bool {
+	switch field {
+	case "name", "host", "user", "port", "tag", "note":
+		return true
+	}
+	return false
+}
+
+func matchTerm(term string, c config.SSHConfig, m meta.Meta) bool {
+	field, pattern, ok := strings.Cut(term, ":")
+	if !ok {
+		return matchPattern(term, c.Name) || matchPattern(term, c.Host)
+	}
+
+	switch field {
+	case "name":
+		return matchPattern(pattern, c.Name)
+	case "host":
+		return matchPattern(pattern, c.Host)
+	case "user":
+		return matchPattern(pattern, c.User)
+	case "port":
+		return matchPattern(pattern, c.Port)
+	case "tag":
+		for _, tag := range m.Tags {
+			if matchPattern(pattern, tag) {
+				return true
+			}
+		}
+		return false
+	case "note":
+		return matchPattern(pattern, m.Note)
+	}
+	return false
+}
+
+func matchPattern(pattern string, value string) bool {
+	pattern = strings.ToLower(pattern)
+	value = strings.ToLower(value)
+
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := filepath.Match(pattern, value)
+		return err == nil && ok
+	}
+	return strings.Contains(value, pattern)
+}
+
+// Candidates merges the ssh config hosts with the direct connections from
+// history. Config hosts come first, history entries whose alias no longer
+// exists in the config are skipped, and duplicates are removed by UniqueKey.
+func Candidates(configs []config.SSHConfig, historyList []history.SSHHistory) []config.SSHConfig {
+	seen := make(map[string]bool)
+	result := make([]config.SSHConfig, 0, len(configs)+len(historyList))
+
+	for _, c := range configs {
+		if seen[c.UniqueKey()] {
+			continue
+		}
+		seen[c.UniqueKey()] = true
+		result = append(result, c)
+	}
+
+	for _, h := range historyList {
+		c := h.Connection
+		if strings.HasPrefix(c.Name, config.MissingConfig) {
+			continue
+		}
+		c.CleanName()
+		if seen[c.UniqueKey()] {
+			continue
+		}
+		seen[c.UniqueKey()] = true
+		result = append(result, c)
+	}
+
+	return result
+}
@@ -0,0 +1,228 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/ssh"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// Host is a single target of a batch command
+type Host struct {
+	Name string
+	Args []string
+}
+
+// Options controls how a batch command is executed
+type Options struct {
+	Concurrency int
+	Timeout     time.Duration
+	Grouped     bool
+	SSHArgs     []string
+	Stdout      io.Writer
+	// IKnow bypasses the guards of the hosts, as --i-know does for ssh
+	IKnow bool
+}
+
+// Result holds the outcome of the command on one host
+type Result struct {
+	Host     Host
+	ExitCode int
+	Duration time.Duration
+	Err      error
+}
+
+// TimedOut reports whether the command was killed by the per-host timeout
+func (r Result) TimedOut() bool {
+	return errors.Is(r.Err, context.DeadlineExceeded)
+}
+
+// HostFromConfig builds the ssh arguments that identify a host
+func HostFromConfig(c config.SSHConfig) Host {
+	if c.IsDirectSSH() {
+		return Host{Name: c.Host, Args: ssh.GenerateCommandArgs(c)}
+	}
+	return Host{Name: c.Name, Args: []string{c.Name}}
+}
+
+// Run executes the command on all hosts, at most opts.Concurrency at a time,
+// and returns one result per host in the order the hosts were given. The
+// guards of the hosts are applied first, one host at a time, and the hosts
+// they refuse get an error.
+func Run(ctx context.Context, hosts []Host, command []string, opts Options) []Result {
+	return run(ctx, hosts, command, opts, settings.Get())
+}
+
+func run(ctx context.Context, hosts []Host, command []string, opts Options, s settings.Settings) []Result {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	width := 0
+	for _, h := range hosts {
+		width = max(width, len(h.Name))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	results := make([]Result, len(hosts))
+
+	sshArgs := make([][]string, len(hosts))
+	for i, h := range hosts {
+		args := append(append([]string{}, opts.SSHArgs...), nonEmpty(h.Args)...)
+		args, err := ssh.GuardUnrecorded(args, ssh.Options{IKnow: opts.IKnow}, s)
+		if err != nil {
+			results[i] = Result{Host: h, ExitCode: -1, Err: err}
+			fmt.Fprintf(opts.Stdout, "[%-*s] not connecting, %v\n", width, h.Name, err)
+			continue
+		}
+		sshArgs[i] = args
+	}
+
+	for i, h := range hosts {
+		if results[i].Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if opts.Grouped {
+				var buf bytes.Buffer
+				results[i] = runOne(ctx, h, sshArgs[i], command, opts, s, &buf)
+
+				mu.Lock()
+				fmt.Fprintf(opts.Stdout, "── %s (exit %d) ──\n", h.Name, results[i].ExitCode)
+				_, _ = opts.Stdout.Write(buf.Bytes())
+				mu.Unlock()
+				return
+			}
+
+			w := &prefixWriter{mu: &mu, out: opts.Stdout, prefix: fmt.Sprintf("[%-*s] ", width, h.Name)}
+			results[i] = runOne(ctx, h, sshArgs[i], command, opts, s, w)
+			w.Flush()
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// runOne runs the command with the ssh arguments of h
+func runOne(ctx context.Context, h Host, sshArgs, command []string, opts Options, s settings.Settings, out io.Writer) Result {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := append(append([]string{}, sshArgs...), command...)
+
+	transport := ssh.TransportFor(s.Transport, args)
+	cmd := transport.Command(ctx, args)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Don't hang on grandchildren holding the output pipe after a timeout
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{Host: h, Duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		result.ExitCode = -1
+		result.Err = ctx.Err()
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.ExitCode = -1
+		result.Err = err
+	}
+
+	return result
+}
+
+func nonEmpty(args []string) []string {
+	out := make([]string, 0, len(args))
+	for _, a := range args {
+		if a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// prefixWriter writes complete lines to out, each prefixed with the host name
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "%s%s", w.prefix, w.buf[:i+1])
+		w.mu.Unlock()
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any trailing output that did not end with a newline
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	w.mu.Lock()
+	fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+	w.mu.Unlock()
+	w.buf = nil
+}
+
+// Summary renders the exit status of every host as a table
+func Summary(results []Result) string {
+	rows := make([]table.Row, 0, len(results))
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.TimedOut():
+			status = "timeout"
+		case r.Err != nil:
+			status = "error"
+		case r.ExitCode != 0:
+			status = "failed"
+		}
+
+		rows = append(rows, table.Row{
+			r.Host.Name,
+			status,
+			strconv.Itoa(r.ExitCode),
+			r.Duration.Round(time.Millisecond).String(),
+		})
+	}
+
+	return theme.PrintTable(rows, theme.ExecTable)
+}
@@ -0,0 +1,324 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ArgKind tells shell completion what a command's arguments are
+type ArgKind int
+
+const (
+	NoArgs ArgKind = iota
+	AliasArgs
+	HostArgs
+	TunnelArgs
+)
+
+// Command describes a ggh command, its flags and its subcommands
+type Command struct {
+	Name    string
+	Aliases []string
+	Usage   string
+	Summary string
+	Action  Action
+	Hidden  bool
+	MinArgs int
+	// MaxArgs is the maximum number of positional arguments, -1 for no limit
+	MaxArgs int
+	// Interspersed allows flags after positional arguments. Commands that
+	// forward their arguments, like exec, stop parsing at the first one.
+	Interspersed bool
+	// Raw commands get their arguments without any flag parsing
+	Raw   bool
+	Flags func(fs *flag.FlagSet)
+	// Required are the flags that must be given a value
+	Required  []string
+	Complete  ArgKind
+	ValidArgs []string
+	Sub       []*Command
+}
+
+// Invocation is the result of parsing the command line
+type Invocation struct {
+	Action  Action
+	Command *Command
+	Args    []string
+	// IKnow is set by a leading --i-know, bypassing the guards of the host
+	IKnow bool
+	flags *flag.FlagSet
+}
+
+// UsageError is returned for command lines ggh doesn't understand
+type UsageError struct {
+	Command *Command
+	Message string
+}
+
+func (e *UsageError) Error() string {
+	name := "ggh"
+	if e.Command != nil && e.Command != Root {
+		name = "ggh " + e.Command.Path()
+	}
+	return fmt.Sprintf("%s: %s\nRun '%s --help' for usage.", name, e.Message, name)
+}
+
+// Path returns the words leading to the command, e.g. "config add"
+func (c *Command) Path() string {
+	for _, path := range paths(Root, nil) {
+		if path[len(path)-1] == c {
+			names := make([]string, 0, len(path))
+			for _, p := range path[1:] {
+				names = append(names, p.Name)
+			}
+			return strings.Join(names, " ")
+		}
+	}
+	return c.Name
+}
+
+func paths(c *Command, prefix []*Command) [][]*Command {
+	current := append(append([]*Command{}, prefix...), c)
+	result := [][]*Command{current}
+	for _, sub := range c.Sub {
+		result = append(result, paths(sub, current)...)
+	}
+	return result
+}
+
+// Find returns the subcommand called name, or nil
+func (c *Command) Find(name string) *Command {
+	for _, sub := range c.Sub {
+		if sub.Name == name {
+			return sub
+		}
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// FlagSet returns a fresh flag set with the command's flags
+func (c *Command) FlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if c.Flags != nil {
+		c.Flags(fs)
+	}
+	return fs
+}
+
+// Help returns the usage text of the command
+func (c *Command) Help() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Usage:\n  %s\n", c.Usage)
+	if c.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", c.Summary)
+	}
+
+	visible := make([]*Command, 0, len(c.Sub))
+	width := 0
+	for _, sub := range c.Sub {
+		if !sub.Hidden {
+			visible = append(visible, sub)
+			width = max(width, len(sub.names()))
+		}
+	}
+	if len(visible) > 0 {
+		b.WriteString("\nCommands:\n")
+		for _, sub := range visible {
+			fmt.Fprintf(&b, "  %-*s  %s\n", width, sub.names(), firstLine(sub.Summary))
+		}
+	}
+
+	var defaults bytes.Buffer
+	fs := c.FlagSet()
+	fs.SetOutput(&defaults)
+	fs.PrintDefaults()
+
+	b.WriteString("\nFlags:\n")
+	b.WriteString(defaults.String())
+	b.WriteString("  -h, --help\n    \tshow this help\n")
+
+	return b.String()
+}
+
+func (c *Command) names() string {
+	return strings.Join(append([]string{c.Name}, c.Aliases...), ", ")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// parse parses the flags and positional arguments of c
+func (c *Command) parse(args []string) (Invocation, error) {
+	inv := Invocation{Action: c.Action, Command: c}
+
+	if c.Raw {
+		inv.Args = args
+		return inv, nil
+	}
+
+	fs := c.FlagSet()
+	inv.flags = fs
+
+	// Everything after -- is positional, flags are only looked for before
+	var rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return Invocation{Action: ShowHelp, Command: c}, nil
+			}
+			return inv, &UsageError{Command: c, Message: err.Error()}
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		if !c.Interspersed {
+			inv.Args = append(inv.Args, args...)
+			break
+		}
+		inv.Args = append(inv.Args, args[0])
+		args = args[1:]
+	}
+	inv.Args = append(inv.Args, rest...)
+
+	switch {
+	case len(inv.Args) < c.MinArgs:
+		return inv, &UsageError{Command: c, Message: fmt.Sprintf("expected at least %d argument(s), got %d", c.MinArgs, len(inv.Args))}
+	case c.MaxArgs >= 0 && len(inv.Args) > c.MaxArgs:
+		if c.MaxArgs == 0 {
+			return inv, &UsageError{Command: c, Message: fmt.Sprintf("unexpected argument %q", inv.Args[0])}
+		}
+		return inv, &UsageError{Command: c, Message: fmt.Sprintf("expected at most %d argument(s), got %d", c.MaxArgs, len(inv.Args))}
+	}
+
+	for _, name := range c.Required {
+		if fs.Lookup(name).Value.String() == "" {
+			return inv, &UsageError{Command: c, Message: fmt.Sprintf("-%s is required", name)}
+		}
+	}
+
+	return inv, nil
+}
+
+// String returns the value of a string flag
+func (inv Invocation) String(name string) string {
+	v, _ := inv.get(name).(string)
+	return v
+}
+
+// Bool returns the value of a bool flag
+func (inv Invocation) Bool(name string) bool {
+	v, _ := inv.get(name).(bool)
+	return v
+}
+
+// Int returns the value of an int flag
+func (inv Invocation) Int(name string) int {
+	v, _ := inv.get(name).(int)
+	return v
+}
+
+// Float returns the value of a float flag
+func (inv Invocation) Float(name string) float64 {
+	v, _ := inv.get(name).(float64)
+	return v
+}
+
+// Duration returns the value of a duration flag
+func (inv Invocation) Duration(name string) time.Duration {
+	v, _ := inv.get(name).(time.Duration)
+	return v
+}
+
+// IsSet reports whether the flag was given on the command line
+func (inv Invocation) IsSet(name string) bool {
+	set := false
+	if inv.flags != nil {
+		inv.flags.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				set = true
+			}
+		})
+	}
+	return set
+}
+
+func (inv Invocation) get(name string) any {
+	if inv.flags == nil {
+		return nil
+	}
+	f := inv.flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	if getter, ok := f.Value.(flag.Getter); ok {
+		return getter.Get()
+	}
+	return nil
+}
+
+// suggest returns the visible command names closest to name
+func suggest(c *Command, name string) []string {
+	trimmed := strings.TrimLeft(name, "-")
+	limit := min(2, max(1, len(trimmed)/2))
+
+	var result []string
+	for _, sub := range c.Sub {
+		if sub.Hidden {
+			continue
+		}
+		for _, candidate := range append([]string{sub.Name}, sub.Aliases...) {
+			if strings.HasPrefix(candidate, "-") != strings.HasPrefix(name, "-") {
+				continue
+			}
+			if distance(trimmed, strings.TrimLeft(candidate, "-")) <= limit {
+				result = append(result, candidate)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
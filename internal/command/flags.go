@@ -1,53 +1,471 @@
 package command
 
 import (
-	"os"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
 )
 
 type Action int
 
 const (
 	PassThrough Action = iota
+	PassThroughExact
 	InteractiveHistory
 	InteractiveConfig
-	InteractiveConfigWithSearch
 	ListHistory
 	ListConfig
 	InteractiveTunnels
 	ListTunnels
 	SelectTunnels
 	ShowVersion
+	ShowHelp
+	Exec
+	AddConfig
+	EditConfig
+	RemoveConfig
+	HistoryDoctor
+	Completion
+	CompletionScript
+	Last
+	RunTool
+	RecordSession
+	ListRecordings
+	PlayRecording
+	ListMux
+	StopMux
+	Route
+	ListKnownHosts
+	InteractiveKnownHosts
+	ListKeys
+	ListAgent
 )
 
-func Which() (Action, string) {
-	if len(os.Args) == 1 {
-		return InteractiveHistory, ""
-	}
-
-	if len(os.Args) == 2 {
-		switch os.Args[1] {
-		case "-v", "--version", "version":
-			return ShowVersion, ""
-		case "--history":
-			return ListHistory, ""
-		case "--config":
-			return ListConfig, ""
-		case "--tunnels":
-			return ListTunnels, ""
-		case "tunnels":
-			return InteractiveTunnels, ""
-		case "-t":
-			return SelectTunnels, ""
-		case "-":
-			return InteractiveConfig, ""
+func hostFlags(fs *flag.FlagSet) {
+	fs.String("hostname", "", "HostName of the host")
+	fs.String("user", "", "User to log in as")
+	fs.String("port", "", "Port to connect to")
+	fs.String("key", "", "IdentityFile to use")
+}
+
+// toolFlags are the flags of the commands wrapping other ssh tools
+func toolFlags(fs *flag.FlagSet) {
+	fs.Bool("config", false, "pick the host from ~/.ssh/config instead of history")
+	fs.String("J", "", "comma separated jump hosts, as for ssh -J")
+}
+
+// outputFlags adds --output and its short form -o to listing commands
+func outputFlags(fs *flag.FlagSet) {
+	format := new(string)
+	usage := "output format: table, json, yaml, csv, tsv or plain (default table on a terminal, plain otherwise)"
+	fs.StringVar(format, "output", "", usage)
+	fs.StringVar(format, "o", "", "shorthand for -output")
+}
+
+// IKnowFlag comes before any command to connect past the guards of
+// production hosts
+const IKnowFlag = "--i-know"
+
+// Root is the ggh command tree
+var Root = &Command{
+	Name:  "ggh",
+	Usage: "ggh [command] [flags] | ggh [--] [ssh arguments]",
+	Summary: "Recall your SSH sessions. Without arguments ggh lists your history.\n" +
+		"Anything that isn't a ggh command is passed to ssh and saved in history.\n" +
+		"A single name that isn't an alias or a known host is fuzzy matched against\n" +
+		"them first. Use -- to pass arguments exactly as given: ggh -- history\n" +
+		"Start with --i-know to connect past the guards of a host, it is logged.",
+	Action: InteractiveHistory,
+	Sub: []*Command{
+		{
+			Name:     "-",
+			Usage:    "ggh - [SEARCH]",
+			Summary:  "Select a host from ~/.ssh/config, optionally filtered by SEARCH",
+			Action:   InteractiveConfig,
+			MaxArgs:  1,
+			Complete: AliasArgs,
+		},
+		{
+			Name:     "--exact",
+			Usage:    "ggh --exact [ssh arguments]",
+			Summary:  "Pass the arguments to ssh without fuzzy matching the host",
+			Action:   PassThroughExact,
+			Raw:      true,
+			Complete: HostArgs,
+		},
+		{
+			Name:     "--record",
+			Usage:    "ggh --record [ssh arguments]",
+			Summary:  "Connect and record the session to ~/.ggh/recordings,\nwithout arguments the host is picked from history",
+			Action:   RecordSession,
+			Raw:      true,
+			Complete: HostArgs,
+		},
+		{
+			Name:    "--history",
+			Usage:   "ggh --history [-o FORMAT]",
+			Flags:   outputFlags,
+			Summary: "List your connection history",
+			Action:  ListHistory,
+		},
+		{
+			Name:    "--config",
+			Usage:   "ggh --config [-o FORMAT]",
+			Flags:   outputFlags,
+			Summary: "List the hosts in ~/.ssh/config",
+			Action:  ListConfig,
+		},
+		{
+			Name:    "--tunnels",
+			Usage:   "ggh --tunnels [-o FORMAT]",
+			Flags:   outputFlags,
+			Summary: "List the saved tunnels",
+			Action:  ListTunnels,
+		},
+		{
+			Name:  "--known-hosts",
+			Usage: "ggh --known-hosts [-stale] [-o FORMAT]",
+			Flags: func(fs *flag.FlagSet) {
+				outputFlags(fs)
+				fs.Bool("stale", false, "only the entries for no host of the ssh config or history")
+			},
+			Summary: "List the entries of ~/.ssh/known_hosts with the hosts using them",
+			Action:  ListKnownHosts,
+		},
+		{
+			Name:    "-t",
+			Usage:   "ggh -t",
+			Summary: "Select tunnels, then select a host to connect to",
+			Action:  SelectTunnels,
+		},
+		{
+			Name:     "--tunnel",
+			Usage:    "ggh --tunnel TUNNEL...",
+			Summary:  "Apply the named tunnels, then select a host to connect to",
+			Action:   SelectTunnels,
+			MinArgs:  1,
+			MaxArgs:  -1,
+			Complete: TunnelArgs,
+		},
+		{
+			Name:    "tunnels",
+			Usage:   "ggh tunnels",
+			Summary: "Create, edit and delete tunnels",
+			Action:  InteractiveTunnels,
+		},
+		{
+			Name:    "last",
+			Usage:   "ggh last [N]",
+			Summary: "Reconnect to the Nth most recent history entry (default 1)\nwith its original arguments and tunnels, without the selector",
+			Action:  Last,
+			MaxArgs: 1,
+		},
+		{
+			Name:    "known-hosts",
+			Usage:   "ggh known-hosts",
+			Summary: "Remove the keys of rebuilt servers and stale entries from ~/.ssh/known_hosts,\na copy is saved in ~/.ggh/backups first",
+			Action:  InteractiveKnownHosts,
+		},
+		{
+			Name:    "keys",
+			Usage:   "ggh keys [-o FORMAT]",
+			Summary: "List the keys in ~/.ssh and the ones your hosts use, with the hosts using them",
+			Action:  ListKeys,
+			Flags:   outputFlags,
+		},
+		{
+			Name:    "agent",
+			Usage:   "ggh agent [-o FORMAT]",
+			Summary: "List the keys loaded in ssh-agent with the hosts using them",
+			Action:  ListAgent,
+			Flags:   outputFlags,
+		},
+		{
+			Name:     "route",
+			Usage:    "ggh route [HOST]",
+			Summary:  "Connect through jump hosts picked in order, the route is saved in history.\nWithout HOST the target is picked first",
+			Action:   Route,
+			MaxArgs:  1,
+			Complete: HostArgs,
+		},
+		{
+			Name:     "exec",
+			Usage:    "ggh exec -q QUERY [flags] -- COMMAND [ARGS...]",
+			Summary:  "Run a command over ssh on every host matching QUERY",
+			Action:   Exec,
+			MinArgs:  1,
+			MaxArgs:  -1,
+			Required: []string{"q"},
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("q", "", "query selecting the hosts, e.g. 'name:web-*' or 'host:10.0.*'")
+				fs.Int("c", 10, "maximum number of hosts running at the same time")
+				fs.Duration("timeout", 30*time.Second, "per-host timeout, 0 disables it")
+				fs.Bool("group", false, "print the output grouped per host instead of prefixed lines")
+			},
+		},
+		{
+			Name:     "mosh",
+			Usage:    "ggh mosh [-config] [-J JUMP] [HOST]",
+			Summary:  "Open a mosh session, picking the host from history unless HOST is given",
+			Action:   RunTool,
+			MaxArgs:  1,
+			Flags:    toolFlags,
+			Complete: HostArgs,
+		},
+		{
+			Name:     "sftp",
+			Usage:    "ggh sftp [-config] [-J JUMP] [HOST]",
+			Summary:  "Open an sftp session, picking the host from history unless HOST is given",
+			Action:   RunTool,
+			MaxArgs:  1,
+			Flags:    toolFlags,
+			Complete: HostArgs,
+		},
+		{
+			Name:    "scp",
+			Usage:   "ggh scp [scp arguments] SOURCE... TARGET",
+			Summary: "Run scp, paths written :PATH are on a host picked from history",
+			Action:  RunTool,
+			MaxArgs: -1,
+			Raw:     true,
+		},
+		{
+			Name:    "rsync",
+			Usage:   "ggh rsync [rsync arguments] SOURCE... TARGET",
+			Summary: "Run rsync over ssh, paths written :PATH are on a host picked from history",
+			Action:  RunTool,
+			MaxArgs: -1,
+			Raw:     true,
+		},
+		{
+			Name:    "config",
+			Usage:   "ggh config COMMAND",
+			Summary: "Add, edit and remove hosts in ~/.ssh/config",
+			Sub: []*Command{
+				{
+					Name:         "add",
+					Usage:        "ggh config add [ALIAS] [flags]",
+					Summary:      "Add a Host block, opens a form unless -hostname is given",
+					Action:       AddConfig,
+					MaxArgs:      1,
+					Interspersed: true,
+					Flags: func(fs *flag.FlagSet) {
+						hostFlags(fs)
+						fs.Bool("include", false, "write the host to the ggh managed Include file ~/.ssh/config.d/ggh")
+					},
+				},
+				{
+					Name:         "edit",
+					Usage:        "ggh config edit ALIAS [flags]",
+					Summary:      "Edit a Host block, opens a form unless a flag is given",
+					Action:       EditConfig,
+					MinArgs:      1,
+					MaxArgs:      1,
+					Interspersed: true,
+					Flags:        hostFlags,
+					Complete:     AliasArgs,
+				},
+				{
+					Name:     "rm",
+					Usage:    "ggh config rm ALIAS",
+					Summary:  "Remove a Host block",
+					Action:   RemoveConfig,
+					MinArgs:  1,
+					MaxArgs:  1,
+					Complete: AliasArgs,
+				},
+			},
+		},
+		{
+			Name:    "history",
+			Usage:   "ggh history COMMAND",
+			Summary: "Manage your connection history",
+			Sub: []*Command{
+				{
+					Name:    "doctor",
+					Usage:   "ggh history doctor [-relink | -direct | -delete]",
+					Summary: "Repair entries whose alias vanished from ~/.ssh/config,\nwithout flags the entries are listed interactively",
+					Action:  HistoryDoctor,
+					Flags: func(fs *flag.FlagSet) {
+						fs.Bool("relink", false, "re-link every orphan that has a suggested host")
+						fs.Bool("direct", false, "convert every orphan to a direct connection")
+						fs.Bool("delete", false, "delete every orphan")
+					},
+				},
+			},
+		},
+		{
+			Name:    "recordings",
+			Usage:   "ggh recordings COMMAND",
+			Summary: "List and replay recorded sessions",
+			Sub: []*Command{
+				{
+					Name:    "ls",
+					Usage:   "ggh recordings ls [-o FORMAT]",
+					Summary: "List the recorded sessions, the most recent first",
+					Action:  ListRecordings,
+					Flags:   outputFlags,
+				},
+				{
+					Name:    "play",
+					Usage:   "ggh recordings play [flags] NAME",
+					Summary: "Replay a recorded session in the terminal",
+					Action:  PlayRecording,
+					MinArgs: 1,
+					MaxArgs: 1,
+					Flags: func(fs *flag.FlagSet) {
+						fs.Float64("speed", 1, "replay speed, 2 plays twice as fast")
+						fs.Duration("idle", 2*time.Second, "longest pause between two outputs, 0 keeps them all")
+					},
+				},
+			},
+		},
+		{
+			Name:    "mux",
+			Usage:   "ggh mux COMMAND",
+			Summary: "List and stop the shared master connections in ~/.ggh/cm",
+			Sub: []*Command{
+				{
+					Name:    "ls",
+					Usage:   "ggh mux ls [-o FORMAT]",
+					Summary: "List the master connections, the oldest first",
+					Action:  ListMux,
+					Flags:   outputFlags,
+				},
+				{
+					Name:     "stop",
+					Usage:    "ggh mux stop HOST",
+					Summary:  "Close the master connections to HOST, an alias, a HostName or USER@HOST",
+					Action:   StopMux,
+					MinArgs:  1,
+					MaxArgs:  1,
+					Complete: HostArgs,
+				},
+			},
+		},
+		{
+			Name:      "completion",
+			Usage:     "ggh completion bash|zsh|fish",
+			Summary:   "Print the shell completion script",
+			Action:    CompletionScript,
+			MinArgs:   1,
+			MaxArgs:   1,
+			ValidArgs: []string{"bash", "zsh", "fish"},
+		},
+		{
+			Name:    "__complete",
+			Usage:   "ggh __complete [WORD...]",
+			Summary: "Print the completions of the last word",
+			Action:  Completion,
+			Hidden:  true,
+			Raw:     true,
+		},
+		{
+			Name:    "version",
+			Aliases: []string{"-v", "--version"},
+			Usage:   "ggh version",
+			Summary: "Print the ggh version",
+			Action:  ShowVersion,
+		},
+		{
+			Name:    "help",
+			Aliases: []string{"-h", "--help"},
+			Usage:   "ggh help [COMMAND...]",
+			Summary: "Show the help of ggh or of a command",
+			Action:  ShowHelp,
+			MaxArgs: -1,
+		},
+	},
+}
+
+// Parse works out what to do with the command line arguments, without the
+// program name. Arguments that aren't a ggh command are passed to ssh, and
+// so are the ones a command doesn't take. A leading --i-know sets IKnow and
+// the rest is parsed as usual.
+func Parse(args []string) (Invocation, error) {
+	if len(args) > 0 && args[0] == IKnowFlag {
+		inv, err := Parse(args[1:])
+		inv.IKnow = true
+		return inv, err
+	}
+
+	if len(args) == 0 {
+		return Invocation{Action: InteractiveHistory, Command: Root}, nil
+	}
+
+	if args[0] == "--" {
+		return Invocation{Action: PassThroughExact, Command: Root, Args: args[1:]}, nil
+	}
+
+	inv, err := parseCommand(args)
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) && !strings.HasPrefix(args[0], "--") && args[0] != "-" {
+		// ssh takes -v and -t too and hosts can be named like a command,
+		// arguments the command doesn't take are meant for ssh
+		return Invocation{Action: PassThrough, Command: Root, Args: args}, nil
+	}
+	if err != nil {
+		return inv, err
+	}
+
+	if inv.Action == ShowHelp && inv.Command.Name == "help" {
+		return helpFor(inv.Args)
+	}
+
+	return inv, nil
+}
+
+// parseCommand parses the arguments of the command named by args[0]
+func parseCommand(args []string) (Invocation, error) {
+	c := Root.Find(args[0])
+	if c == nil {
+		// ssh has no long options, so this is a mistyped ggh flag
+		if strings.HasPrefix(args[0], "--") {
+			return Invocation{}, unknown(Root, args[0])
+		}
+		return Invocation{Action: PassThrough, Command: Root, Args: args}, nil
+	}
+	args = args[1:]
+
+	for len(c.Sub) > 0 {
+		if len(args) == 0 {
+			return Invocation{}, &UsageError{Command: c, Message: "missing command"}
+		}
+		if args[0] == "-h" || args[0] == "--help" {
+			return Invocation{Action: ShowHelp, Command: c}, nil
 		}
+		sub := c.Find(args[0])
+		if sub == nil {
+			return Invocation{}, unknown(c, args[0])
+		}
+		c, args = sub, args[1:]
 	}
 
-	if len(os.Args) == 3 {
-		if os.Args[1] == "-" {
-			return InteractiveConfigWithSearch, os.Args[2]
+	return c.parse(args)
+}
+
+// helpFor resolves "ggh help config add" to the help of "config add"
+func helpFor(words []string) (Invocation, error) {
+	c := Root
+	for _, word := range words {
+		sub := c.Find(word)
+		if sub == nil {
+			return Invocation{}, unknown(c, word)
 		}
+		c = sub
 	}
+	return Invocation{Action: ShowHelp, Command: c}, nil
+}
 
-	return PassThrough, ""
+func unknown(c *Command, name string) error {
+	msg := fmt.Sprintf("unknown command %q", name)
+	if strings.HasPrefix(name, "-") {
+		msg = fmt.Sprintf("unknown flag %q", name)
+	}
+	if suggestions := suggest(c, name); len(suggestions) > 0 {
+		msg += fmt.Sprintf("\nDid you mean %s?", strings.Join(suggestions, " or "))
+	}
+	return &UsageError{Command: c, Message: msg}
 }
@@ -0,0 +1,180 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args    string
+		action  Action
+		command string
+		rest    []string
+	}{
+		{"", InteractiveHistory, "ggh", nil},
+		{"-v", ShowVersion, "version", nil},
+		{"--version", ShowVersion, "version", nil},
+		{"version", ShowVersion, "version", nil},
+		{"--history", ListHistory, "--history", nil},
+		{"--config", ListConfig, "--config", nil},
+		{"--tunnels", ListTunnels, "--tunnels", nil},
+		{"tunnels", InteractiveTunnels, "tunnels", nil},
+		{"last", Last, "last", nil},
+		{"last 2", Last, "last", []string{"2"}},
+		{"mosh", RunTool, "mosh", nil},
+		{"--record", RecordSession, "--record", nil},
+		{"--record -p 2222 root@10.0.0.1", RecordSession, "--record", []string{"-p", "2222", "root@10.0.0.1"}},
+		{"recordings ls -o json", ListRecordings, "ls", nil},
+		{"recordings play -speed 2 stage_20240101-000000", PlayRecording, "play", []string{"stage_20240101-000000"}},
+		{"mux ls", ListMux, "ls", nil},
+		{"--known-hosts -stale -o json", ListKnownHosts, "--known-hosts", nil},
+		{"known-hosts", InteractiveKnownHosts, "known-hosts", nil},
+		{"keys -o json", ListKeys, "keys", nil},
+		{"agent", ListAgent, "agent", nil},
+		{"route bastion", Route, "route", []string{"bastion"}},
+		{"mux stop bastion", StopMux, "stop", []string{"bastion"}},
+		{"sftp -config", RunTool, "sftp", nil},
+		{"sftp stage", RunTool, "sftp", []string{"stage"}},
+		{"scp -r dir :/srv", RunTool, "scp", []string{"-r", "dir", ":/srv"}},
+		{"rsync -avz --delete dir/ :/srv/", RunTool, "rsync", []string{"-avz", "--delete", "dir/", ":/srv/"}},
+		{"-t", SelectTunnels, "-t", nil},
+		{"--tunnel pg redis", SelectTunnels, "--tunnel", []string{"pg", "redis"}},
+		{"-", InteractiveConfig, "-", nil},
+		{"- stage", InteractiveConfig, "-", []string{"stage"}},
+		{"exec -q web -- uptime -a", Exec, "exec", []string{"uptime", "-a"}},
+		{"exec -q web uptime -a", Exec, "exec", []string{"uptime", "-a"}},
+		{"config add", AddConfig, "add", nil},
+		{"config add web -hostname web.com -include", AddConfig, "add", []string{"web"}},
+		{"config edit web -port 22", EditConfig, "edit", []string{"web"}},
+		{"config rm web", RemoveConfig, "rm", []string{"web"}},
+		{"history doctor", HistoryDoctor, "doctor", nil},
+		{"history doctor -relink", HistoryDoctor, "doctor", nil},
+		{"completion zsh", CompletionScript, "completion", []string{"zsh"}},
+		{"__complete - st", Completion, "__complete", []string{"-", "st"}},
+		{"__complete -t --help", Completion, "__complete", []string{"-t", "--help"}},
+		{"root@server.com", PassThrough, "ggh", []string{"root@server.com"}},
+		{"root@server.com -p2440", PassThrough, "ggh", []string{"root@server.com", "-p2440"}},
+		{"-p 22 -i key root@host", PassThrough, "ggh", []string{"-p", "22", "-i", "key", "root@host"}},
+		{"stage", PassThrough, "ggh", []string{"stage"}},
+		{"-v host", PassThrough, "ggh", []string{"-v", "host"}},
+		{"-t host uptime", PassThrough, "ggh", []string{"-t", "host", "uptime"}},
+		{"history", PassThrough, "ggh", []string{"history"}},
+		{"history uptime", PassThrough, "ggh", []string{"history", "uptime"}},
+		{"exec uptime", PassThrough, "ggh", []string{"exec", "uptime"}},
+		{"mux", PassThrough, "ggh", []string{"mux"}},
+		{"route -p 2222 bastion", PassThrough, "ggh", []string{"route", "-p", "2222", "bastion"}},
+		{"tunnels extra", PassThrough, "ggh", []string{"tunnels", "extra"}},
+		{"config ad", PassThrough, "ggh", []string{"config", "ad"}},
+		{"-- history", PassThroughExact, "ggh", []string{"history"}},
+		{"-- -t host", PassThroughExact, "ggh", []string{"-t", "host"}},
+		{"--exact prod-db", PassThroughExact, "--exact", []string{"prod-db"}},
+		{"--exact -p 22 host", PassThroughExact, "--exact", []string{"-p", "22", "host"}},
+		{"-h", ShowHelp, "ggh", nil},
+		{"--help", ShowHelp, "ggh", nil},
+		{"help", ShowHelp, "ggh", nil},
+		{"help config add", ShowHelp, "add", nil},
+		{"tunnels --help", ShowHelp, "tunnels", nil},
+		{"--history -h", ShowHelp, "--history", nil},
+		{"config --help", ShowHelp, "config", nil},
+		{"config edit web --help", ShowHelp, "edit", nil},
+		{"exec --help", ShowHelp, "exec", nil},
+		{"--i-know", InteractiveHistory, "ggh", nil},
+		{"--i-know prod-db", PassThrough, "ggh", []string{"prod-db"}},
+		{"--i-know last 2", Last, "last", []string{"2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			inv, err := Parse(strings.Fields(tt.args))
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if inv.Action != tt.action {
+				t.Errorf("Parse(%q) action = %v, want %v", tt.args, inv.Action, tt.action)
+			}
+			if inv.Command.Name != tt.command {
+				t.Errorf("Parse(%q) command = %v, want %v", tt.args, inv.Command.Name, tt.command)
+			}
+			if strings.Join(inv.Args, " ") != strings.Join(tt.rest, " ") {
+				t.Errorf("Parse(%q) args = %q, want %q", tt.args, inv.Args, tt.rest)
+			}
+			if inv.IKnow != strings.HasPrefix(tt.args, IKnowFlag) {
+				t.Errorf("Parse(%q) IKnow = %v", tt.args, inv.IKnow)
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	inv, err := Parse(strings.Fields("exec -q name:web-* -c 3 -timeout 5s -group -- df -h"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if inv.String("q") != "name:web-*" || inv.Int("c") != 3 || inv.Duration("timeout") != 5*time.Second || !inv.Bool("group") {
+		t.Errorf("Parse() flags = q:%q c:%d timeout:%v group:%v", inv.String("q"), inv.Int("c"), inv.Duration("timeout"), inv.Bool("group"))
+	}
+
+	inv, _ = Parse(strings.Fields("config edit web -user bob"))
+	if !inv.IsSet("user") || inv.IsSet("port") {
+		t.Errorf("IsSet() user:%v port:%v, want true false", inv.IsSet("user"), inv.IsSet("port"))
+	}
+
+	inv, _ = Parse(strings.Fields("exec -q web uptime"))
+	if inv.Int("c") != 10 || inv.Duration("timeout") != 30*time.Second {
+		t.Errorf("defaults c:%d timeout:%v", inv.Int("c"), inv.Duration("timeout"))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"--histroy", `unknown flag "--histroy"`},
+		{"--histroy", "Did you mean --history?"},
+		{"--tunnels extra", `ggh --tunnels: unexpected argument "extra"`},
+		{"--history --json", "flag provided but not defined: -json"},
+		{"--known-hosts -nope", "Run 'ggh --known-hosts --help' for usage."},
+		{"- a b", "expected at most 1 argument(s), got 2"},
+		{"--tunnel", "expected at least 1 argument(s), got 0"},
+		{"help nope", `unknown command "nope"`},
+		{"help config ad", "Did you mean add?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			_, err := Parse(strings.Fields(tt.args))
+			var usageErr *UsageError
+			if !errors.As(err, &usageErr) {
+				t.Fatalf("Parse(%q) error = %v, want a UsageError", tt.args, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.args, err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHelp(t *testing.T) {
+	help := Root.Help()
+	for _, want := range []string{"Usage:", "exec", "config", "--history", "-v, --version"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("root help is missing %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "__complete") {
+		t.Errorf("root help lists hidden commands:\n%s", help)
+	}
+
+	add := Root.Find("config").Find("add")
+	if add.Path() != "config add" {
+		t.Errorf("Path() = %q, want %q", add.Path(), "config add")
+	}
+	if help := add.Help(); !strings.Contains(help, "-hostname") || !strings.Contains(help, "-h, --help") {
+		t.Errorf("config add help is missing flags:\n%s", help)
+	}
+}
@@ -2,12 +2,14 @@ package command
 
 import (
 	"log"
-	"os/exec"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/ssh"
 )
 
+// CheckSSH exits when the configured transport, ssh by default, can't be run
 func CheckSSH() {
-	_, err := exec.LookPath("ssh")
-	if err != nil {
-		log.Fatal("ssh is not installed")
+	if err := ssh.CheckTransport(settings.Get().Transport); err != nil {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,209 @@
+package completion
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/command"
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/tunnel"
+)
+
+// Source provides the dynamic completion values
+type Source struct {
+	Aliases func() []string
+	Hosts   func() []string
+	Tunnels func() []string
+}
+
+// DefaultSource reads the ssh config, history and tunnel files
+var DefaultSource = Source{
+	Aliases: config.Aliases,
+	Hosts:   historyHosts,
+	Tunnels: tunnelNames,
+}
+
+// Complete returns the candidates for the last word of args, the words
+// typed after "ggh". The last word is the one being completed. Commands
+// and flags come from the command tree, so completion follows the parser.
+func Complete(args []string, src Source) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	prev, cur := args[:len(args)-1], args[len(args)-1]
+
+	if len(prev) == 0 {
+		var candidates []string
+		for _, sub := range command.Root.Sub {
+			if sub.Hidden {
+				continue
+			}
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				if strings.HasPrefix(name, "-") == strings.HasPrefix(cur, "-") {
+					candidates = append(candidates, name)
+				}
+			}
+		}
+		if !strings.HasPrefix(cur, "-") {
+			candidates = append(candidates, hosts(src)...)
+		}
+		return withPrefix(candidates, cur)
+	}
+
+	c := command.Root.Find(prev[0])
+	if c == nil || prev[0] == "--" {
+		// Anything else is passed to ssh, which takes a host
+		if strings.HasPrefix(cur, "-") {
+			return nil
+		}
+		return withPrefix(hosts(src), cur)
+	}
+	prev = prev[1:]
+
+	for len(c.Sub) > 0 {
+		if len(prev) == 0 {
+			names := make([]string, 0, len(c.Sub))
+			for _, sub := range c.Sub {
+				names = append(names, sub.Name)
+			}
+			return withPrefix(names, cur)
+		}
+		sub := c.Find(prev[0])
+		if sub == nil {
+			return nil
+		}
+		c, prev = sub, prev[1:]
+	}
+
+	if c.Raw {
+		// Raw commands like --exact forward their arguments to ssh
+		if c.Complete == command.HostArgs && !strings.HasPrefix(cur, "-") {
+			return withPrefix(hosts(src), cur)
+		}
+		return nil
+	}
+
+	fs := c.FlagSet()
+	if strings.HasPrefix(cur, "-") {
+		var flags []string
+		fs.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, "-"+f.Name)
+		})
+		return withPrefix(flags, cur)
+	}
+
+	// Work out the positional arguments, skipping flags and their values
+	var positional []string
+	for i := 0; i < len(prev); i++ {
+		if !strings.HasPrefix(prev[i], "-") {
+			positional = append(positional, prev[i])
+			continue
+		}
+		f := fs.Lookup(strings.TrimLeft(prev[i], "-"))
+		if f != nil && !isBool(f) && !strings.Contains(prev[i], "=") {
+			i++
+		}
+	}
+	if len(prev) > 0 && strings.HasPrefix(prev[len(prev)-1], "-") {
+		if f := fs.Lookup(strings.TrimLeft(prev[len(prev)-1], "-")); f != nil && !isBool(f) {
+			// Completing the value of a flag
+			return nil
+		}
+	}
+	if c.MaxArgs >= 0 && len(positional) >= c.MaxArgs {
+		return nil
+	}
+
+	var candidates []string
+	switch c.Complete {
+	case command.AliasArgs:
+		candidates = src.Aliases()
+	case command.HostArgs:
+		candidates = hosts(src)
+	case command.TunnelArgs:
+		candidates = without(src.Tunnels(), positional)
+	default:
+		candidates = c.ValidArgs
+	}
+
+	return withPrefix(candidates, cur)
+}
+
+func isBool(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func hosts(src Source) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+	for _, h := range append(src.Aliases(), src.Hosts()...) {
+		if !seen[h] {
+			seen[h] = true
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+func historyHosts() []string {
+	list, err := history.Load()
+	if err != nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		c := item.Connection
+		switch {
+		case !c.IsDirectSSH():
+			result = append(result, c.Name)
+		case c.User != "":
+			result = append(result, c.User+"@"+c.Host)
+		default:
+			result = append(result, c.Host)
+		}
+	}
+	return result
+}
+
+func tunnelNames() []string {
+	tunnels, err := tunnel.LoadTunnels()
+	if err != nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(tunnels))
+	for _, t := range tunnels {
+		result = append(result, t.Name)
+	}
+	return result
+}
+
+func without(list []string, exclude []string) []string {
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		found := false
+		for _, e := range exclude {
+			if item == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func withPrefix(list []string, prefix string) []string {
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if strings.HasPrefix(item, prefix) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
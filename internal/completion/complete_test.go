@@ -0,0 +1,62 @@
+package completion
+
+import (
+	"strings"
+	"testing"
+)
+
+var testSource = Source{
+	Aliases: func() []string { return []string{"stage", "prod-db", "prod-web"} },
+	Hosts:   func() []string { return []string{"prod-db", "root@10.0.0.1"} },
+	Tunnels: func() []string { return []string{"pg", "redis", "socks"} },
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{""}, []string{"tunnels", "last", "known-hosts", "keys", "agent", "route", "exec", "mosh", "sftp", "scp", "rsync", "config", "history", "recordings", "mux", "completion", "version", "help", "stage", "prod-db", "prod-web", "root@10.0.0.1"}},
+		{[]string{"pro"}, []string{"prod-db", "prod-web"}},
+		{[]string{"--h"}, []string{"--history", "--help"}},
+		{[]string{"--e"}, []string{"--exact"}},
+		{[]string{"-", ""}, []string{"stage", "prod-db", "prod-web"}},
+		{[]string{"-", "st"}, []string{"stage"}},
+		{[]string{"-", "stage", ""}, []string{}},
+		{[]string{"--tunnel", "pg", ""}, []string{"redis", "socks"}},
+		{[]string{"config", ""}, []string{"add", "edit", "rm"}},
+		{[]string{"config", "rm", "prod-"}, []string{"prod-db", "prod-web"}},
+		{[]string{"config", "add", "web", "-h"}, []string{"-hostname"}},
+		{[]string{"history", ""}, []string{"doctor"}},
+		{[]string{"history", "doctor", "-d"}, []string{"-delete", "-direct"}},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"exec", "-t"}, []string{"-timeout"}},
+		{[]string{"exec", "-q", ""}, []string{}},
+		{[]string{"__complete", ""}, []string{}},
+		{[]string{"--", "st"}, []string{"stage"}},
+		{[]string{"--exact", "prod"}, []string{"prod-db", "prod-web"}},
+		{[]string{"-p", "2222", "root@"}, []string{"root@10.0.0.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			got := Complete(tt.args, testSource)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Complete(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script, err := Script(shell)
+		if err != nil || !strings.Contains(script, "ggh __complete") {
+			t.Errorf("Script(%q) = %q, %v", shell, script, err)
+		}
+	}
+
+	if _, err := Script("tcsh"); err == nil {
+		t.Errorf("Script() with an unsupported shell should fail")
+	}
+}
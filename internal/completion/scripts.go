@@ -0,0 +1,49 @@
+package completion
+
+import "fmt"
+
+const bashScript = `# bash completion for ggh
+_ggh() {
+    local IFS=$'\n'
+    COMPREPLY=($(ggh __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _ggh ggh
+`
+
+const zshScript = `#compdef ggh
+# zsh completion for ggh
+
+_ggh() {
+    local -a completions
+    completions=("${(@f)$(ggh __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -a completions
+}
+
+if [ "$funcstack[1]" = "_ggh" ]; then
+    _ggh "$@"
+else
+    compdef _ggh ggh
+fi
+`
+
+const fishScript = `# fish completion for ggh
+function __ggh_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    ggh __complete $tokens[2..-1] 2>/dev/null
+end
+
+complete -c ggh -f -a '(__ggh_complete)'
+`
+
+// Script returns the completion script for shell
+func Script(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return bashScript, nil
+	case "zsh":
+		return zshScript, nil
+	case "fish":
+		return fishScript, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish)", shell)
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Aliases returns the names of every Host in the ssh config and its
+// Includes. Unlike Parse it only reads Host and Include lines, which keeps
+// it fast on large Include trees, and it skips wildcard patterns.
+func Aliases() []string {
+	aliases := make([]string, 0)
+	seen := make(map[string]bool)
+	collectAliases(ConfigFilePath(), seen, &aliases)
+	return aliases
+}
+
+func collectAliases(path string, seen map[string]bool, aliases *[]string) {
+	// seen holds both visited files and found aliases, files are prefixed
+	// to keep them apart and to break Include cycles
+	if seen["file:"+path] {
+		return
+	}
+	seen["file:"+path] = true
+
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keyword, value := splitOption(scanner.Text())
+		switch {
+		case strings.EqualFold(keyword, "Include"):
+			for _, pattern := range strings.Fields(value) {
+				for _, include := range includePaths(pattern) {
+					collectAliases(include, seen, aliases)
+				}
+			}
+		case strings.EqualFold(keyword, "Host"):
+			value, _ = cutComment(value)
+			for _, name := range strings.Fields(value) {
+				if strings.ContainsAny(name, "*?!") || seen["host:"+name] {
+					continue
+				}
+				seen["host:"+name] = true
+				*aliases = append(*aliases, name)
+			}
+		}
+	}
+}
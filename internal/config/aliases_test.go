@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh", "conf.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"config":         "Include conf.d/*\nInclude config\n\nHost *\n\tUser root\n\nHost stage prod\n\tHostName x\n",
+		"conf.d/work":    "Host work-?\n\tUser me\nHost work-1\n\tHostName y\nInclude ~/.ssh/conf.d/nested\n",
+		"conf.d/nested":  "Host nested # the inner one\n\tHostName z\n",
+		"conf.d/ignored": "Host stage\n\tHostName duplicate\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(home, ".ssh", name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := strings.Join(Aliases(), ",")
+	if got != "stage,nested,work-1,prod" {
+		t.Errorf("Aliases() = %v, want stage,nested,work-1,prod", got)
+	}
+}
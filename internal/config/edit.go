@@ -0,0 +1,454 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const managedInclude = "config.d/ggh"
+
+// ConfigFilePath returns the path of the main ssh config file
+func ConfigFilePath() string {
+	return filepath.Join(GetSshDir(), "config")
+}
+
+// ManagedFilePath returns the path of the Include file ggh writes hosts to
+func ManagedFilePath() string {
+	return filepath.Join(GetSshDir(), managedInclude)
+}
+
+// Validate checks that the config can be written as a Host block
+func (c *SSHConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if strings.ContainsAny(c.Name, " \t*?!,") {
+		return fmt.Errorf("alias %q must be a single name without spaces or patterns", c.Name)
+	}
+	if c.Host == "" {
+		return fmt.Errorf("hostname cannot be empty")
+	}
+	if c.Port != "" {
+		port, err := strconv.Atoi(c.Port)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port: %s (must be 1-65535)", c.Port)
+		}
+	}
+	return nil
+}
+
+// RenderHost formats a config as an ssh_config Host block
+func RenderHost(c SSHConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n", c.Name)
+	for _, o := range hostOptions(c) {
+		if o.value != "" {
+			fmt.Fprintf(&b, "\t%s %s\n", o.keyword, o.value)
+		}
+	}
+	return b.String()
+}
+
+type hostOption struct {
+	keyword string
+	value   string
+}
+
+func hostOptions(c SSHConfig) []hostOption {
+	return []hostOption{
+		{"HostName", c.Host},
+		{"User", c.User},
+		{"Port", c.Port},
+		{"IdentityFile", c.Key},
+	}
+}
+
+// AddHost appends a new Host block to the main ssh config, or to the ggh
+// managed Include file when managed is true.
+func AddHost(c SSHConfig, managed bool) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if existing, _ := GetConfig(c.Name); existing.Name != "" {
+		return fmt.Errorf("host '%s' already exists", c.Name)
+	}
+
+	if managed {
+		if err := ensureManagedInclude(); err != nil {
+			return err
+		}
+	}
+
+	path := ConfigFilePath()
+	if managed {
+		path = ManagedFilePath()
+	}
+
+	content, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	return writeChecked(path, addHostBlock(content, c), expectHost(c))
+}
+
+// UpdateHost rewrites the Host block of alias with the values of c. Options
+// ggh doesn't know about, comments and the rest of the file are left as is.
+func UpdateHost(alias string, c SSHConfig) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if c.Name != alias {
+		if existing, _ := GetConfig(c.Name); existing.Name != "" {
+			return fmt.Errorf("host '%s' already exists", c.Name)
+		}
+	}
+
+	path, err := FindHostFile(alias)
+	if err != nil {
+		return err
+	}
+
+	content, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	updated, err := updateHostBlock(content, alias, c)
+	if err != nil {
+		return err
+	}
+
+	return writeChecked(path, updated, expectHost(c))
+}
+
+// RemoveHost deletes the Host block of alias
+func RemoveHost(alias string) error {
+	path, err := FindHostFile(alias)
+	if err != nil {
+		return err
+	}
+
+	content, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	updated, err := removeHostBlock(content, alias)
+	if err != nil {
+		return err
+	}
+
+	return writeChecked(path, updated, func(list []SSHConfig) error {
+		for _, item := range list {
+			if item.Name == alias {
+				return fmt.Errorf("host '%s' is still defined", alias)
+			}
+		}
+		return nil
+	})
+}
+
+// FindHostFile returns the config file, the main one or one of its
+// Includes, that holds the Host block of alias.
+func FindHostFile(alias string) (string, error) {
+	files := []string{ConfigFilePath()}
+
+	content, err := readConfigFile(ConfigFilePath())
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(content, "\n") {
+		keyword, value := splitOption(line)
+		if strings.EqualFold(keyword, "Include") && value != "" {
+			files = append(files, includePaths(value)...)
+		}
+	}
+
+	for _, file := range files {
+		content, err := readConfigFile(file)
+		if err != nil {
+			continue
+		}
+		if start, _, err := findHostBlock(strings.Split(content, "\n"), alias); err == nil && start >= 0 {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("host '%s' not found in %s", alias, ConfigFilePath())
+}
+
+func includePaths(pattern string) []string {
+	if strings.HasPrefix(pattern, "~") {
+		pattern = filepath.Join(HomeDir(), pattern[1:])
+	} else if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(GetSshDir(), pattern)
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+	return paths
+}
+
+// ensureManagedInclude makes the main config include the ggh managed file
+func ensureManagedInclude() error {
+	path := ConfigFilePath()
+	content, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		keyword, value := splitOption(line)
+		if strings.EqualFold(keyword, "Include") && (value == managedInclude || value == ManagedFilePath()) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ManagedFilePath()), 0700); err != nil {
+		return err
+	}
+
+	// Include has to come before any Host block to apply to every host
+	return writeChecked(path, "Include "+managedInclude+"\n\n"+content, nil)
+}
+
+func expectHost(c SSHConfig) func([]SSHConfig) error {
+	return func(list []SSHConfig) error {
+		for _, item := range list {
+			if item.Name == c.Name {
+				if item.Host != c.Host || item.User != c.User || item.Port != c.Port || item.Key != c.Key {
+					return fmt.Errorf("host '%s' parsed back as %+v", c.Name, item)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("host '%s' not found after writing", c.Name)
+	}
+}
+
+// writeChecked backs up path, writes content and re-parses the ssh config.
+// If check rejects the result the previous content is restored.
+func writeChecked(path string, content string, check func([]SSHConfig) error) error {
+	previous, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if _, err := Backup(path); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if check == nil {
+		return nil
+	}
+
+	list, err := ParseWithSearch("", GetConfigFile())
+	if err == nil {
+		err = check(list)
+	}
+	if err != nil {
+		_ = os.WriteFile(path, []byte(previous), mode)
+		return fmt.Errorf("verification failed, %s was restored: %w", path, err)
+	}
+
+	return nil
+}
+
+// Backup copies path into ~/.ggh/backups and returns the copy's location
+func Backup(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(HomeDir(), ".ggh", "backups")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	backup := filepath.Join(dir, fmt.Sprintf("%s.%s", filepath.Base(path), time.Now().Format("20060102-150405.000")))
+	if err := os.WriteFile(backup, content, 0600); err != nil {
+		return "", err
+	}
+
+	return backup, nil
+}
+
+func readConfigFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// splitOption returns the keyword and value of an ssh_config line,
+// accepting both "Keyword value" and "Keyword=value".
+func splitOption(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", ""
+	}
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+	value := strings.TrimLeft(line[i:], " \t")
+	value = strings.TrimPrefix(value, "=")
+	return line[:i], strings.TrimSpace(value)
+}
+
+// findHostBlock returns the line range [start, end) of the "Host alias"
+// block, or -1 when there is none. The range stops at the last option,
+// so comments and blank lines preceding the next block stay with it.
+func findHostBlock(lines []string, alias string) (int, int, error) {
+	start := -1
+	for i, line := range lines {
+		keyword, value := splitOption(line)
+		if !strings.EqualFold(keyword, "Host") {
+			continue
+		}
+		value, _ = cutComment(value)
+		patterns := strings.Fields(value)
+		if len(patterns) == 1 && patterns[0] == alias {
+			start = i
+			break
+		}
+		for _, p := range patterns {
+			if p == alias {
+				return -1, -1, fmt.Errorf("host '%s' shares its block with other patterns (%s), edit it by hand", alias, value)
+			}
+		}
+	}
+	if start < 0 {
+		return -1, -1, nil
+	}
+
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		keyword, _ := splitOption(lines[i])
+		if strings.EqualFold(keyword, "Host") || strings.EqualFold(keyword, "Match") {
+			break
+		}
+		if keyword != "" {
+			end = i + 1
+		}
+	}
+	return start, end, nil
+}
+
+func addHostBlock(content string, c SSHConfig) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" && !strings.HasSuffix(content, "\n\n") {
+		content += "\n"
+	}
+	return content + RenderHost(c)
+}
+
+func updateHostBlock(content string, alias string, c SSHConfig) (string, error) {
+	lines := strings.Split(content, "\n")
+	start, end, err := findHostBlock(lines, alias)
+	if err != nil {
+		return "", err
+	}
+	if start < 0 {
+		return "", fmt.Errorf("host '%s' not found", alias)
+	}
+
+	block := append([]string{}, lines[start:end]...)
+	_, comment := cutComment(block[0])
+	block[0] = leadingSpace(block[0]) + "Host " + c.Name + comment
+
+	indent := "\t"
+	if len(block) > 1 {
+		indent = leadingSpace(block[1])
+	}
+
+	for _, o := range hostOptions(c) {
+		found := -1
+		for i := 1; i < len(block); i++ {
+			keyword, _ := splitOption(block[i])
+			if strings.EqualFold(keyword, o.keyword) {
+				found = i
+				break
+			}
+		}
+
+		switch {
+		case found >= 0 && o.value == "":
+			block = append(block[:found], block[found+1:]...)
+		case found >= 0:
+			block[found] = leadingSpace(block[found]) + o.keyword + " " + o.value
+		case o.value != "":
+			block = append(block, indent+o.keyword+" "+o.value)
+		}
+	}
+
+	result := append([]string{}, lines[:start]...)
+	result = append(result, block...)
+	result = append(result, lines[end:]...)
+	return strings.Join(result, "\n"), nil
+}
+
+func removeHostBlock(content string, alias string) (string, error) {
+	lines := strings.Split(content, "\n")
+	start, end, err := findHostBlock(lines, alias)
+	if err != nil {
+		return "", err
+	}
+	if start < 0 {
+		return "", fmt.Errorf("host '%s' not found", alias)
+	}
+
+	// The comment lines right above the block describe it
+	for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+		start--
+	}
+
+	// Drop the blank line separating the block from the next one
+	if end < len(lines)-1 && strings.TrimSpace(lines[end]) == "" {
+		end++
+	}
+
+	result := append([]string{}, lines[:start]...)
+	result = append(result, lines[end:]...)
+	return strings.Join(result, "\n"), nil
+}
+
+// cutComment splits a trailing comment, with the space before it, off a
+// line
+func cutComment(line string) (string, string) {
+	for i := 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') {
+			rest := strings.TrimRight(line[:i], " \t")
+			return rest, line[len(rest):]
+		}
+	}
+	return line, ""
+}
+
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
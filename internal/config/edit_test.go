@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var editable = `# Global settings
+Host *
+	ServerAliveInterval 30
+
+# Staging box
+Host stage
+	HostName stage.example.com
+	User deploy
+	ForwardAgent yes
+
+Host prod
+	HostName prod.example.com
+	Port 2222
+`
+
+func TestUpdateHostBlock(t *testing.T) {
+	got, err := updateHostBlock(editable, "stage", SSHConfig{Name: "staging", Host: "10.0.0.5", Port: "22"})
+	if err != nil {
+		t.Fatalf("updateHostBlock() error = %v", err)
+	}
+
+	want := `# Global settings
+Host *
+	ServerAliveInterval 30
+
+# Staging box
+Host staging
+	HostName 10.0.0.5
+	ForwardAgent yes
+	Port 22
+
+Host prod
+	HostName prod.example.com
+	Port 2222
+`
+	if got != want {
+		t.Errorf("updateHostBlock() =\n%s\nwant\n%s", got, want)
+	}
+
+	// a comment after the alias stays
+	got, err = updateHostBlock("Host db  # primary\n\tHostName 10.0.0.7\n", "db", SSHConfig{Name: "db-1", Host: "10.0.0.8"})
+	if err != nil || got != "Host db-1  # primary\n\tHostName 10.0.0.8\n" {
+		t.Errorf("updateHostBlock() with a comment = %q, %v", got, err)
+	}
+
+	if _, err := updateHostBlock(editable, "missing", SSHConfig{Name: "missing", Host: "x"}); err == nil {
+		t.Errorf("updateHostBlock() on a missing host should fail")
+	}
+}
+
+func TestRemoveHostBlock(t *testing.T) {
+	got, err := removeHostBlock(editable, "stage")
+	if err != nil {
+		t.Fatalf("removeHostBlock() error = %v", err)
+	}
+
+	// the comment describing the block goes with it
+	want := `# Global settings
+Host *
+	ServerAliveInterval 30
+
+Host prod
+	HostName prod.example.com
+	Port 2222
+`
+	if got != want {
+		t.Errorf("removeHostBlock() =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = removeHostBlock("Host db # primary\n\tHostName x\n", "db")
+	if err != nil || got != "" {
+		t.Errorf("removeHostBlock() with a comment = %q, %v", got, err)
+	}
+
+	if _, err := removeHostBlock("Host a b\n\tHostName x\n", "a"); err == nil {
+		t.Errorf("removeHostBlock() on a shared block should fail")
+	}
+}
+
+func TestAddHost(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ConfigFilePath(), []byte(editable), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := SSHConfig{Name: "web", Host: "web.example.com", User: "ubuntu"}
+	if err := AddHost(c, true); err != nil {
+		t.Fatalf("AddHost() error = %v", err)
+	}
+
+	main, _ := os.ReadFile(ConfigFilePath())
+	if !strings.HasPrefix(string(main), "Include config.d/ggh\n\n"+editable) {
+		t.Errorf("main config was not preserved:\n%s", main)
+	}
+
+	managed, _ := os.ReadFile(ManagedFilePath())
+	if string(managed) != RenderHost(c) {
+		t.Errorf("managed file = %q, want %q", managed, RenderHost(c))
+	}
+
+	if err := AddHost(c, false); err == nil {
+		t.Errorf("AddHost() with a duplicate alias should fail")
+	}
+
+	backups, _ := os.ReadDir(filepath.Join(home, ".ggh", "backups"))
+	if len(backups) == 0 {
+		t.Errorf("no backup was made")
+	}
+
+	c.Port = "2200"
+	if err := UpdateHost("web", c); err != nil {
+		t.Fatalf("UpdateHost() error = %v", err)
+	}
+	if got, _ := GetConfig("web"); got.Port != "2200" {
+		t.Errorf("UpdateHost() port = %q, want 2200", got.Port)
+	}
+
+	if err := RemoveHost("web"); err != nil {
+		t.Fatalf("RemoveHost() error = %v", err)
+	}
+	if got, _ := GetConfig("web"); got.Name != "" {
+		t.Errorf("RemoveHost() left %+v", got)
+	}
+}
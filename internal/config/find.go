@@ -0,0 +1,30 @@
+package config
+
+import "github.com/MrLonely14/ggh/internal/fuzzy"
+
+// Find fuzzy matches query against the names and hosts of list and returns
+// the strong matches, best first. unique is true when a single host is
+// better than all the others, so it can be connected to directly.
+func Find(query string, list []SSHConfig) (matches []SSHConfig, unique bool) {
+	values := make([][]string, 0, len(list))
+	for _, c := range list {
+		fields := []string{c.Host}
+		if !c.IsDirectSSH() {
+			fields = append(fields, c.Name)
+		}
+		values = append(values, fields)
+	}
+
+	// a subsequence isn't enough to take the name away from ssh
+	var results []fuzzy.Result
+	for _, r := range fuzzy.Filter(query, values) {
+		if r.Quality.Strong() {
+			results = append(results, r)
+			matches = append(matches, list[r.Index])
+		}
+	}
+
+	unique = len(results) == 1 || len(results) > 1 && results[1].Quality < results[0].Quality
+
+	return matches, unique
+}
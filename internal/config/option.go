@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Option returns the value ssh uses for keyword when connecting to host:
+// the first one given in the ssh config and its Includes, outside a Host
+// block or in one matching host. Match blocks can't be evaluated here and
+// are skipped.
+func Option(host, keyword string) string {
+	value, _ := findOption(ConfigFilePath(), host, keyword, make(map[string]bool))
+	return value
+}
+
+func findOption(path, host, keyword string, seen map[string]bool) (string, bool) {
+	// seen breaks Include cycles
+	if seen[path] {
+		return "", false
+	}
+	seen[path] = true
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	applies := true
+	for _, line := range strings.Split(string(content), "\n") {
+		k, v := splitOption(line)
+		switch {
+		case strings.EqualFold(k, "Host"):
+			v, _ = cutComment(v)
+			applies = hostMatches(host, strings.Fields(v))
+		case strings.EqualFold(k, "Match"):
+			applies = false
+		case !applies:
+		case strings.EqualFold(k, "Include"):
+			for _, pattern := range strings.Fields(v) {
+				for _, include := range includePaths(pattern) {
+					if value, ok := findOption(include, host, keyword, seen); ok {
+						return value, true
+					}
+				}
+			}
+		case strings.EqualFold(k, keyword):
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// hostMatches reports whether host matches the patterns of a Host line, one
+// of them matching it and none of the negated ones
+func hostMatches(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	matched := false
+	for _, p := range patterns {
+		negated := strings.HasPrefix(p, "!")
+		ok, err := filepath.Match(strings.ToLower(strings.TrimPrefix(p, "!")), host)
+		if err != nil || !ok {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
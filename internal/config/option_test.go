@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOption(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh", "conf.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"config":      "Include conf.d/*\n\nHost prod-* !prod-lab\n\tForwardAgent yes\n\nMatch user root\n\tForwardAgent no\n\nHost *\n\tForwardAgent no\n\tUser root\n",
+		"conf.d/work": "Host work\n\tForwardAgent=~/agent.sock\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(home, ".ssh", name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		host    string
+		keyword string
+		want    string
+	}{
+		{"prod-db", "ForwardAgent", "yes"},
+		{"PROD-db", "forwardagent", "yes"},
+		{"prod-lab", "ForwardAgent", "no"},
+		{"work", "ForwardAgent", "~/agent.sock"},
+		{"stage", "User", "root"},
+		{"stage", "Port", ""},
+	}
+	for _, tt := range tests {
+		if got := Option(tt.host, tt.keyword); got != tt.want {
+			t.Errorf("Option(%q, %q) = %q, want %q", tt.host, tt.keyword, got, tt.want)
+		}
+	}
+}
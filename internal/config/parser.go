@@ -8,6 +8,8 @@ import (
 
 	"path/filepath"
 
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/output"
 	"github.com/MrLonely14/ggh/internal/theme"
 	"github.com/charmbracelet/bubbles/table"
 )
@@ -36,6 +38,17 @@ func (c *SSHConfig) UniqueKey() string {
 	return fmt.Sprintf("%s%s%s", c.Host, c.Port, c.User)
 }
 
+// DisplayName returns the alias, or user@host for a direct connection
+func (c *SSHConfig) DisplayName() string {
+	if !c.IsDirectSSH() {
+		return c.Name
+	}
+	if c.User != "" {
+		return c.User + "@" + c.Host
+	}
+	return c.Host
+}
+
 func (c *SSHConfig) CleanName() {
 	if c.Name == DirectSSH {
 		c.Name = ""
@@ -43,6 +56,13 @@ func (c *SSHConfig) CleanName() {
 	c.Name = strings.TrimPrefix(c.Name, MissingConfig)
 }
 
+// MetaKey returns the key of the tags and note of the host, its UniqueKey
+// once the name is clean
+func (c SSHConfig) MetaKey() string {
+	c.CleanName()
+	return c.UniqueKey()
+}
+
 func Parse(configFile string) ([]SSHConfig, error) {
 	return ParseWithSearch("", configFile)
 }
@@ -152,13 +172,44 @@ func ParseInclude(search string, path string) ([]SSHConfig, error) {
 	return results, nil
 }
 
-func Print() {
+// RecordKeys are the fields of a config host in machine readable output
+var RecordKeys = []string{"name", "host", "port", "user", "key", "tags", "note", "favorite"}
+
+// Records converts config hosts to output records, with the tags, note and
+// favorite kept for them in store
+func Records(list []SSHConfig, store meta.Store) []output.Record {
+	records := make([]output.Record, 0, len(list))
+	for _, c := range list {
+		m := store.Get(c.MetaKey())
+		records = append(records, output.Record{
+			{Key: "name", Value: c.Name},
+			{Key: "host", Value: c.Host},
+			{Key: "port", Value: c.Port},
+			{Key: "user", Value: c.User},
+			{Key: "key", Value: c.Key},
+			{Key: "tags", Value: strings.Join(m.Tags, ",")},
+			{Key: "note", Value: m.Note},
+			{Key: "favorite", Value: m.Favorite},
+		})
+	}
+	return records
+}
+
+func Print(format output.Format) {
 	list, err := Parse(GetConfigFile())
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	store, _ := meta.Load()
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, RecordKeys, Records(list, store)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if len(list) == 0 {
 		fmt.Println("No configs found in ~/.ssh/config.")
 		return
@@ -166,7 +217,8 @@ func Print() {
 
 	var rows []table.Row
 	for _, history := range list {
-		rows = append(rows, table.Row{history.Name, history.Host, history.Port, history.User, history.Key})
+		m := store.Get(history.MetaKey())
+		rows = append(rows, table.Row{history.Name, history.Host, history.Port, history.User, history.Key, m.TagsColumn(), m.Note})
 	}
 	fmt.Println(theme.PrintTable(rows, theme.ConfigTable))
 
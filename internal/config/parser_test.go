@@ -2,6 +2,8 @@ package config
 
 import (
 	"testing"
+
+	"github.com/MrLonely14/ggh/internal/meta"
 )
 
 var config = `
@@ -46,3 +48,59 @@ func TestParsingWithSearch(t *testing.T) {
 		t.Errorf("Parsing config file failed: got %v, want %v\n", len(configs), 1)
 	}
 }
+
+func TestFind(t *testing.T) {
+	list := []SSHConfig{
+		{Name: "prod-db", Host: "10.0.0.1"},
+		{Name: "prod-web", Host: "10.0.0.2"},
+		{Name: "stage", Host: "stage.example.com"},
+		{Host: "192.168.1.20", User: "root"},
+	}
+
+	tests := []struct {
+		query   string
+		matches int
+		unique  bool
+		first   string
+	}{
+		{"db", 1, true, "10.0.0.1"},
+		{"prod", 2, false, "10.0.0.1"},
+		{"pdb", 0, false, ""},
+		{"stg", 0, false, ""},
+		{"192.168", 1, true, "192.168.1.20"},
+		{"nothing", 0, false, ""},
+	}
+
+	for _, tt := range tests {
+		matches, unique := Find(tt.query, list)
+		if len(matches) != tt.matches || unique != tt.unique {
+			t.Errorf("Find(%q) = %d matches, unique %v, want %d, %v", tt.query, len(matches), unique, tt.matches, tt.unique)
+			continue
+		}
+		if len(matches) > 0 && matches[0].Host != tt.first {
+			t.Errorf("Find(%q) first match = %v, want %v", tt.query, matches[0].Host, tt.first)
+		}
+	}
+}
+
+func TestRecords(t *testing.T) {
+	list := []SSHConfig{{Name: "host1", Host: "hos1.com", Port: "6743", User: "root"}, {Name: "host2", Host: "192.168.1.11"}}
+	store := meta.Store{}
+	store.Set(list[0].MetaKey(), meta.Meta{Tags: []string{"env:prod", "web"}, Note: "behind the vpn", Favorite: true})
+
+	records := Records(list, store)
+	want := [][]any{
+		{"host1", "hos1.com", "6743", "root", "", "env:prod,web", "behind the vpn", true},
+		{"host2", "192.168.1.11", "", "", "", "", "", false},
+	}
+	for i, r := range records {
+		if len(r) != len(RecordKeys) {
+			t.Fatalf("record %d has %d fields, want %d", i, len(r), len(RecordKeys))
+		}
+		for j, f := range r {
+			if f.Key != RecordKeys[j] || f.Value != want[i][j] {
+				t.Errorf("record %d field %d = %s: %v, want %s: %v", i, j, f.Key, f.Value, RecordKeys[j], want[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Quality is how well a query matches a value, higher is better
+type Quality int
+
+const (
+	None Quality = iota
+	// Subsequence means the query characters appear in order
+	Subsequence
+	Substring
+	Prefix
+	Exact
+)
+
+// Strong reports whether the match is good enough to act on without asking
+func (q Quality) Strong() bool {
+	return q >= Substring
+}
+
+// Rank returns how well query matches value, ignoring case
+func Rank(query string, value string) Quality {
+	query = strings.ToLower(query)
+	value = strings.ToLower(value)
+
+	switch {
+	case query == "" || value == "":
+		return None
+	case query == value:
+		return Exact
+	case strings.HasPrefix(value, query):
+		return Prefix
+	case strings.Contains(value, query):
+		return Substring
+	case isSubsequence(query, value):
+		return Subsequence
+	}
+	return None
+}
+
+func isSubsequence(query string, value string) bool {
+	q := []rune(query)
+	i := 0
+	for _, r := range value {
+		if i < len(q) && q[i] == r {
+			i++
+		}
+	}
+	return i == len(q)
+}
+
+// Result is a matching value and its position in the searched list
+type Result struct {
+	Index   int
+	Quality Quality
+}
+
+// Filter returns the values matching query, best matches first. A value
+// is a list of fields, its quality is the best of its fields.
+func Filter(query string, values [][]string) []Result {
+	results := make([]Result, 0)
+	for i, fields := range values {
+		best := None
+		for _, f := range fields {
+			best = max(best, Rank(query, f))
+		}
+		if best != None {
+			results = append(results, Result{Index: i, Quality: best})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Quality > results[j].Quality
+	})
+	return results
+}
@@ -0,0 +1,45 @@
+package fuzzy
+
+import "testing"
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		query string
+		value string
+		want  Quality
+	}{
+		{"prod-db", "prod-db", Exact},
+		{"PROD", "prod-db", Prefix},
+		{"db", "prod-db", Substring},
+		{"pdb", "prod-db", Subsequence},
+		{"dbp", "prod-db", None},
+		{"", "prod-db", None},
+		{"ñd", "ñandú", Subsequence},
+	}
+
+	for _, tt := range tests {
+		if got := Rank(tt.query, tt.value); got != tt.want {
+			t.Errorf("Rank(%q, %q) = %v, want %v", tt.query, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	values := [][]string{
+		{"staging-web", "10.0.0.1"},
+		{"web", "10.0.0.2"},
+		{"db", "web.internal"},
+		{"cache", "10.0.0.3"},
+	}
+
+	results := Filter("web", values)
+	want := []Result{{1, Exact}, {2, Prefix}, {0, Substring}}
+	if len(results) != len(want) {
+		t.Fatalf("Filter() = %v, want %v", results, want)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("Filter()[%d] = %v, want %v", i, results[i], want[i])
+		}
+	}
+}
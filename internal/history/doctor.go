@@ -0,0 +1,148 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+// RepairAction is what to do with an orphaned history entry
+type RepairAction string
+
+const (
+	// RepairRelink points the entry at another config host
+	RepairRelink RepairAction = "relink"
+	// RepairDirect turns the entry into a direct user@host connection
+	RepairDirect RepairAction = "direct"
+	// RepairDelete removes the entry
+	RepairDelete RepairAction = "delete"
+)
+
+// Orphan is a history entry whose alias no longer exists in the ssh config
+type Orphan struct {
+	Entry SSHHistory
+	// Suggestion is a config host with the same HostName, User and Port,
+	// its Name is empty when there is none.
+	Suggestion config.SSHConfig
+}
+
+// Repair is the action chosen for the orphan named Alias
+type Repair struct {
+	Alias  string
+	Action RepairAction
+	Target string
+}
+
+// FindOrphans returns the entries of list flagged with config.MissingConfig,
+// each with the config host it was most likely renamed to.
+func FindOrphans(list []SSHHistory, configs []config.SSHConfig) []Orphan {
+	orphans := make([]Orphan, 0)
+
+	for _, item := range list {
+		if !strings.HasPrefix(item.Connection.Name, config.MissingConfig) {
+			continue
+		}
+		item.Connection.CleanName()
+
+		orphan := Orphan{Entry: item}
+		for _, c := range configs {
+			if sameEndpoint(item.Connection, c) {
+				orphan.Suggestion = c
+				break
+			}
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans
+}
+
+func sameEndpoint(a config.SSHConfig, b config.SSHConfig) bool {
+	port := func(p string) string {
+		if p == "" {
+			return "22"
+		}
+		return p
+	}
+
+	return a.Host != "" &&
+		strings.EqualFold(a.Host, b.Host) &&
+		a.User == b.User &&
+		port(a.Port) == port(b.Port)
+}
+
+// Doctor returns the orphaned entries of the default history file
+func Doctor() ([]Orphan, error) {
+	list, err := FetchWithDefaultFile()
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := config.Parse(config.GetConfigFile())
+	if err != nil {
+		return nil, err
+	}
+
+	return FindOrphans(list, configs), nil
+}
+
+// ApplyRepairs applies the repairs to the default history file
+func ApplyRepairs(repairs []Repair) error {
+	list, err := Fetch(getFile())
+	if err != nil {
+		return err
+	}
+
+	return saveFile(SSHHistory{}, applyRepairs(list, repairs))
+}
+
+func applyRepairs(list []SSHHistory, repairs []Repair) []SSHHistory {
+	byAlias := make(map[string]Repair)
+	for _, r := range repairs {
+		byAlias[r.Alias] = r
+	}
+
+	seen := make(map[string]bool)
+	saving := make([]SSHHistory, 0, len(list))
+
+	for _, item := range list {
+		if strings.HasPrefix(item.Connection.Name, config.MissingConfig) {
+			item.Connection.CleanName()
+
+			if r, ok := byAlias[item.Connection.Name]; ok {
+				switch r.Action {
+				case RepairRelink:
+					item.Connection.Name = r.Target
+				case RepairDirect:
+					item.Connection.Name = ""
+				case RepairDelete:
+					continue
+				}
+			}
+		}
+
+		// Relinking may point two entries at the same host, keep the newest
+		item.Connection.CleanName()
+		if seen[item.Connection.UniqueKey()] {
+			continue
+		}
+		seen[item.Connection.UniqueKey()] = true
+		saving = append(saving, item)
+	}
+
+	return saving
+}
+
+// Describe returns a one line summary of the repair
+func (r Repair) Describe() string {
+	switch r.Action {
+	case RepairRelink:
+		return fmt.Sprintf("%s → %s", r.Alias, r.Target)
+	case RepairDirect:
+		return fmt.Sprintf("%s → direct connection", r.Alias)
+	case RepairDelete:
+		return fmt.Sprintf("%s → deleted", r.Alias)
+	}
+	return r.Alias
+}
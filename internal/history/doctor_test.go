@@ -0,0 +1,62 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+func TestFindOrphans(t *testing.T) {
+	list := []SSHHistory{
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "old-web", Host: "10.0.0.1", User: "deploy", Port: "22"}},
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "gone", Host: "10.0.0.2"}},
+		{Connection: config.SSHConfig{Name: "db", Host: "10.0.0.3"}},
+		{Connection: config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.4"}},
+	}
+	configs := []config.SSHConfig{
+		{Name: "web", Host: "10.0.0.1", User: "deploy"},
+		{Name: "db", Host: "10.0.0.3"},
+	}
+
+	orphans := FindOrphans(list, configs)
+	if len(orphans) != 2 {
+		t.Fatalf("FindOrphans() returned %d orphans, want 2", len(orphans))
+	}
+	if orphans[0].Entry.Connection.Name != "old-web" || orphans[0].Suggestion.Name != "web" {
+		t.Errorf("orphan 0 = %+v", orphans[0])
+	}
+	if orphans[1].Suggestion.Name != "" {
+		t.Errorf("orphan 1 should have no suggestion, got %+v", orphans[1].Suggestion)
+	}
+}
+
+func TestApplyRepairs(t *testing.T) {
+	now := time.Now()
+	list := []SSHHistory{
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "old-web", Host: "10.0.0.1"}, Date: now},
+		{Connection: config.SSHConfig{Name: "web", Host: "10.0.0.1"}, Date: now.Add(-time.Hour)},
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "old-db", Host: "10.0.0.2", User: "pg"}},
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "junk", Host: "10.0.0.3"}},
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "kept", Host: "10.0.0.4"}},
+	}
+
+	got := applyRepairs(list, []Repair{
+		{Alias: "old-web", Action: RepairRelink, Target: "web"},
+		{Alias: "old-db", Action: RepairDirect},
+		{Alias: "junk", Action: RepairDelete},
+	})
+
+	if len(got) != 3 {
+		t.Fatalf("applyRepairs() returned %d entries, want 3: %+v", len(got), got)
+	}
+	if got[0].Connection.Name != "web" || !got[0].Date.Equal(now) {
+		t.Errorf("relinked entry = %+v", got[0])
+	}
+	if got[1].Connection.Name != "" || got[1].Connection.User != "pg" {
+		t.Errorf("direct entry = %+v", got[1].Connection)
+	}
+	if got[2].Connection.Name != "kept" {
+		t.Errorf("untouched entry = %+v", got[2].Connection)
+	}
+}
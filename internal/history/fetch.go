@@ -4,15 +4,54 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/ssh"
 	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/MrLonely14/ggh/internal/tunnel"
 	"github.com/charmbracelet/bubbles/table"
 	"log"
+	"os"
+	"strings"
 	"time"
 )
 
 type SSHHistory struct {
 	Connection config.SSHConfig `json:"connection"`
 	Date       time.Time        `json:"date"`
+	// Args are the ssh arguments the connection was made with, when they
+	// were typed rather than generated from the connection
+	Args []string `json:"args,omitempty"`
+	// Tunnels are the IDs of the tunnels applied to the connection
+	Tunnels []string `json:"tunnels,omitempty"`
+	// Jump is the chain of jump hosts the connection went through, as
+	// given to ssh -J
+	Jump string `json:"jump,omitempty"`
+	// Count is how many times the connection was made
+	Count int `json:"count,omitempty"`
+}
+
+// Connections returns how many times the connection was made, entries
+// saved before they were counted count once
+func (h SSHHistory) Connections() int {
+	return max(h.Count, 1)
+}
+
+// SSHArgs returns the arguments to reconnect to the entry
+func (h SSHHistory) SSHArgs() []string {
+	if len(h.Args) > 0 {
+		return h.Args
+	}
+
+	c := h.Connection
+	c.CleanName()
+	if h.Jump != "" {
+		return ssh.Endpoint{SSHConfig: c, Jump: h.Jump}.Args()
+	}
+	if c.IsDirectSSH() {
+		return ssh.GenerateCommandArgs(c)
+	}
+	return []string{c.Name}
 }
 
 func FetchWithDefaultFile() ([]SSHHistory, error) {
@@ -58,26 +97,95 @@ FormatHistoryLoop:
 	return historyList, nil
 }
 
-func Print() {
+// RecordKeys are the fields of a history entry in machine readable output
+var RecordKeys = []string{"name", "type", "host", "port", "user", "key", "last_login", "tunnels", "jump", "tags", "note", "favorite"}
+
+// Records converts history entries to output records. The type is
+// "config" for aliases of the ssh config, "direct" for user@host
+// connections and "missing" for aliases that vanished from the config.
+// Tunnels are the names of the tunnels applied with the connection,
+// resolved against tunnels. Jump is the chain of jump hosts. The tags, note
+// and favorite are the ones kept for the host in store.
+func Records(list []SSHHistory, tunnels []tunnel.Tunnel, store meta.Store) []output.Record {
+	records := make([]output.Record, 0, len(list))
+	for _, item := range list {
+		c := item.Connection
+		m := store.Get(c.MetaKey())
+		kind := "config"
+		switch {
+		case c.IsDirectSSH():
+			kind = "direct"
+		case strings.HasPrefix(c.Name, config.MissingConfig):
+			kind = "missing"
+		}
+		c.CleanName()
+
+		records = append(records, output.Record{
+			{Key: "name", Value: c.Name},
+			{Key: "type", Value: kind},
+			{Key: "host", Value: c.Host},
+			{Key: "port", Value: c.Port},
+			{Key: "user", Value: c.User},
+			{Key: "key", Value: c.Key},
+			{Key: "last_login", Value: item.Date.Format(time.RFC3339)},
+			{Key: "tunnels", Value: strings.Join(item.TunnelNames(tunnels), ",")},
+			{Key: "jump", Value: item.Jump},
+			{Key: "tags", Value: strings.Join(m.Tags, ",")},
+			{Key: "note", Value: m.Note},
+			{Key: "favorite", Value: m.Favorite},
+		})
+	}
+	return records
+}
+
+// Last returns the nth most recent history entry, starting at 1
+func Last(n int) (SSHHistory, error) {
+	list, err := FetchWithDefaultFile()
+	if err != nil {
+		return SSHHistory{}, err
+	}
+
+	if n < 1 || n > len(list) {
+		return SSHHistory{}, fmt.Errorf("history has %d entries, can't go back %d", len(list), n)
+	}
+
+	return list[n-1], nil
+}
+
+func Print(format output.Format) {
 	list, err := FetchWithDefaultFile()
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	store, _ := meta.Load()
+	if format != output.Table {
+		if err := output.Write(os.Stdout, format, RecordKeys, Records(list, loadTunnels(), store)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if len(list) == 0 {
 		fmt.Println("No history found.")
 		return
 	}
 	var rows []table.Row
 	currentTime := time.Now()
+	tunnels := loadTunnels()
 	for _, history := range list {
+		m := store.Get(history.Connection.MetaKey())
 		rows = append(rows, table.Row{history.Connection.Name,
 			history.Connection.Host,
 			history.Connection.Port,
 			history.Connection.User,
 			history.Connection.Key,
 			fmt.Sprintf("%s", ReadableTime(currentTime.Sub(history.Date))),
+			history.tunnelColumn(tunnels),
+			history.Jump,
+			m.TagsColumn(),
+			m.Note,
 		})
 	}
 
@@ -102,3 +210,44 @@ func ReadableTime(d time.Duration) string {
 
 	return "Long time ago"
 }
+
+// Hosts returns the hosts of the ssh config and the direct connections of
+// history, once each
+func Hosts() []config.SSHConfig {
+	hosts, _ := config.ParseWithSearch("", config.GetConfigFile())
+	list, _ := FetchWithDefaultFile()
+
+	seen := map[string]bool{}
+	for _, c := range hosts {
+		seen[c.UniqueKey()] = true
+	}
+	for _, item := range list {
+		c := item.Connection
+		if !c.IsDirectSSH() {
+			continue
+		}
+		c.CleanName()
+		if !seen[c.UniqueKey()] {
+			seen[c.UniqueKey()] = true
+			hosts = append(hosts, c)
+		}
+	}
+	return hosts
+}
+
+// Load reads the default history file without checking the entries against
+// the ssh config, for callers that only need the stored connections.
+func Load() ([]SSHHistory, error) {
+	var historyList []SSHHistory
+
+	file := getFile()
+	if len(file) == 0 {
+		return historyList, nil
+	}
+
+	if err := json.Unmarshal(file, &historyList); err != nil {
+		return nil, err
+	}
+
+	return historyList, nil
+}
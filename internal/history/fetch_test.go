@@ -1,6 +1,9 @@
 package history
 
 import (
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/tunnel"
 	"testing"
 )
 
@@ -53,3 +56,34 @@ func TestParsing(t *testing.T) {
 		t.Errorf("Parsing config file failed: got %v, want %v\n", history[1].Connection.Host, "host.name")
 	}
 }
+
+func TestRecords(t *testing.T) {
+	list := []SSHHistory{
+		{Connection: config.SSHConfig{Name: "stage", Host: "host.name"}},
+		{Connection: config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.1", User: "root"}},
+		{Connection: config.SSHConfig{Name: config.MissingConfig + "gone", Host: "10.0.0.2"}, Tunnels: []string{"t1", "t2"}},
+	}
+
+	store := meta.Store{}
+	store.Set(list[0].Connection.MetaKey(), meta.Meta{Tags: []string{"env:prod", "db"}, Note: "primary", Favorite: true})
+
+	records := Records(list, []tunnel.Tunnel{{ID: "t1", Name: "pg"}}, store)
+	want := [][2]string{{"stage", "config"}, {"", "direct"}, {"gone", "missing"}}
+	for i, r := range records {
+		if len(r) != len(RecordKeys) {
+			t.Fatalf("record %d has %d fields, want %d", i, len(r), len(RecordKeys))
+		}
+		if r[0].Value != want[i][0] || r[1].Value != want[i][1] {
+			t.Errorf("record %d = %v, want name %q type %q", i, r, want[i][0], want[i][1])
+		}
+	}
+	if got := records[2][7].Value; got != "pg,"+DeletedTunnel {
+		t.Errorf("tunnels = %q, want %q", got, "pg,"+DeletedTunnel)
+	}
+	if got := records[0][9:]; got[0].Value != "env:prod,db" || got[1].Value != "primary" || got[2].Value != true {
+		t.Errorf("meta of stage = %v, want tags %q note %q favorite", got, "env:prod,db", "primary")
+	}
+	if got := records[1][9:]; got[0].Value != "" || got[1].Value != "" || got[2].Value != false {
+		t.Errorf("meta of a host without any = %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package history
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/tunnel"
+)
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry SSHHistory
+		want  []string
+	}{
+		{"stored args", SSHHistory{Connection: config.SSHConfig{Host: "10.0.0.5", User: "root"}, Args: []string{"-A", "root@10.0.0.5", "-p", "2222"}}, []string{"-A", "root@10.0.0.5", "-p", "2222"}},
+		{"alias", SSHHistory{Connection: config.SSHConfig{Name: "stage", Host: "10.0.0.1"}}, []string{"stage"}},
+		{"direct", SSHHistory{Connection: config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.5", User: "root", Port: "2222"}}, []string{"root@10.0.0.5", "", "-p", "2222"}},
+		{"alias through jump hosts", SSHHistory{Connection: config.SSHConfig{Name: "db", Host: "10.1.0.7"}, Jump: "bastion,inner"}, []string{"-J", "bastion,inner", "db"}},
+		{"direct through a jump host", SSHHistory{Connection: config.SSHConfig{Name: config.DirectSSH, Host: "10.1.0.7", Port: "2222"}, Jump: "bastion"}, []string{"-p", "2222", "-J", "bastion", "root@10.1.0.7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.entry.SSHArgs()
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("SSHArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLast(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := Last(1); err == nil {
+		t.Errorf("Last(1) on empty history should fail")
+	}
+
+	AddHistoryFromArgs([]string{"-A", "root@10.0.0.5", "-p", "2222"})
+	time.Sleep(time.Millisecond)
+	AddEntry(SSHHistory{Connection: config.SSHConfig{Host: "10.0.0.6", User: "deploy"}, Tunnels: []string{"t1", "t2"}})
+
+	last, err := Last(1)
+	if err != nil {
+		t.Fatalf("Last(1) error = %v", err)
+	}
+	if last.Connection.Host != "10.0.0.6" || strings.Join(last.Tunnels, ",") != "t1,t2" {
+		t.Errorf("Last(1) = %+v", last)
+	}
+
+	previous, err := Last(2)
+	if err != nil {
+		t.Fatalf("Last(2) error = %v", err)
+	}
+	if strings.Join(previous.SSHArgs(), " ") != "-A root@10.0.0.5 -p 2222" || len(previous.Tunnels) != 0 {
+		t.Errorf("Last(2) = %+v", previous)
+	}
+
+	for _, n := range []int{0, 3} {
+		if _, err := Last(n); err == nil {
+			t.Errorf("Last(%d) should fail", n)
+		}
+	}
+
+	// Replaying an entry moves it back to the top with its tunnels
+	AddEntry(previous)
+	var saved []SSHHistory
+	if err := json.Unmarshal(getFile(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[0].Connection.Host != "10.0.0.5" || strings.Join(saved[1].Tunnels, ",") != "t1,t2" {
+		t.Errorf("history after replay = %+v", saved)
+	}
+}
+
+func TestSavedTunnels(t *testing.T) {
+	all := []tunnel.Tunnel{{ID: "t1", Name: "pg"}, {ID: "t3", Name: "redis"}}
+	entry := SSHHistory{Tunnels: []string{"t3", "t2", "t1"}}
+
+	names := entry.TunnelNames(all)
+	if strings.Join(names, ",") != "redis,"+DeletedTunnel+",pg" {
+		t.Errorf("TunnelNames() = %v", names)
+	}
+
+	saved, deleted := entry.SavedTunnels(all)
+	if len(saved) != 2 || saved[0].Name != "redis" || saved[1].Name != "pg" || deleted != 1 {
+		t.Errorf("SavedTunnels() = %v, %d", saved, deleted)
+	}
+}
+
+func TestAddHistoryFromArgsJump(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	AddHistoryFromArgs([]string{"-J", "deploy@bastion:2200,inner", "root@10.1.0.7"})
+	time.Sleep(time.Millisecond)
+	AddHistoryFromArgs([]string{"-Jbastion", "admin@10.1.0.8"})
+
+	list, err := FetchWithDefaultFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(list))
+	}
+	if list[0].Jump != "bastion" || list[0].Connection.User != "admin" {
+		t.Errorf("attached -J gave %+v", list[0])
+	}
+	if list[1].Jump != "deploy@bastion:2200,inner" || list[1].Connection.User != "root" || list[1].Connection.Host != "10.1.0.7" {
+		t.Errorf("separate -J gave %+v", list[1])
+	}
+}
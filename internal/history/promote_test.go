@@ -0,0 +1,80 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+func writeHistory(t *testing.T, list []SSHHistory) {
+	t.Helper()
+	content, _ := json.Marshal(list)
+	if err := os.WriteFile(getFileLocation(), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	writeHistory(t, []SSHHistory{
+		{Connection: config.SSHConfig{Name: "db", Host: "10.0.0.5", User: "root", Port: "2222"}, Date: newer},
+		{Connection: config.SSHConfig{Host: "10.0.0.5", User: "root", Port: "2222"}, Date: older},
+		{Connection: config.SSHConfig{Host: "10.0.0.6", User: "root"}, Date: older},
+	})
+
+	direct := config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.5", User: "root", Port: "2222"}
+	if err := Promote(direct, "db"); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+
+	var saved []SSHHistory
+	if err := json.Unmarshal(getFile(), &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entry for db reaches the same host, the two are merged
+	if len(saved) != 2 {
+		t.Fatalf("Promote() left %d entries, want 2", len(saved))
+	}
+	if saved[0].Connection.Name != "db" || saved[0].Connection.Host != "10.0.0.5" || saved[0].Connection.Port != "2222" {
+		t.Errorf("promoted entry = %+v", saved[0].Connection)
+	}
+	if !saved[0].Date.Equal(newer) {
+		t.Errorf("promoted entry date = %v, want %v", saved[0].Date, newer)
+	}
+	if saved[0].Connections() != 2 {
+		t.Errorf("promoted entry connections = %d, want 2", saved[0].Connections())
+	}
+	if saved[1].Connection.Host != "10.0.0.6" {
+		t.Errorf("unrelated entry = %+v", saved[1].Connection)
+	}
+
+	if err := Promote(config.SSHConfig{Name: "db", Host: "10.0.0.5"}, "other"); err == nil {
+		t.Errorf("Promote() of a named host should fail")
+	}
+}
+
+func TestPromoteConflict(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	list := []SSHHistory{
+		{Connection: config.SSHConfig{Name: "db", Host: "10.0.0.9"}, Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Connection: config.SSHConfig{Host: "10.0.0.5", User: "root"}, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	writeHistory(t, list)
+	before := getFile()
+
+	// the entry for db reaches another host, merging would lose it
+	if err := Promote(config.SSHConfig{Host: "10.0.0.5", User: "root"}, "db"); err == nil {
+		t.Fatalf("Promote() over an entry for another host should fail")
+	}
+	if string(getFile()) != string(before) {
+		t.Errorf("the refused Promote() changed the history:\n%s", getFile())
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/MrLonely14/ggh/internal/config"
-	"github.com/charmbracelet/bubbles/table"
+	"github.com/MrLonely14/ggh/internal/keys"
 	"os"
 	"strings"
 	"time"
@@ -17,11 +17,12 @@ func AddHistoryFromArgs(args []string) {
 			return
 		}
 
-		AddHistory(localConfig)
+		AddEntry(SSHHistory{Connection: localConfig})
 		return
 	}
 
 	generatedConfig := config.SSHConfig{}
+	jump := ""
 
 	skipNext := false
 	for i, arg := range args {
@@ -39,19 +40,32 @@ func AddHistoryFromArgs(args []string) {
 				generatedConfig.Port = args[i][2:]
 			}
 		case arg == "-i":
-			generatedConfig.Key = args[i+1]
+			// the key is read from the working directory, unlike the ones
+			// of the ssh config
+			generatedConfig.Key = keys.ArgPath(args[i+1])
 			skipNext = true
+		case arg == "-J" && i+1 < len(args):
+			jump = args[i+1]
+			skipNext = true
+		case strings.HasPrefix(arg, "-J"):
+			jump = arg[2:]
 		case strings.Contains(arg, "@"):
 			values := strings.Split(arg, "@")
 			generatedConfig.User = values[0]
 			generatedConfig.Host = values[1]
 		}
 	}
-	AddHistory(generatedConfig)
+	AddEntry(SSHHistory{Connection: generatedConfig, Args: args, Jump: jump})
 }
 
 func AddHistory(c config.SSHConfig) {
-	if c.Host == "" {
+	AddEntry(SSHHistory{Connection: c})
+}
+
+// AddEntry puts the entry at the top of history, dated now, replacing the
+// previous entry of the same connection
+func AddEntry(entry SSHHistory) {
+	if entry.Connection.Host == "" {
 		return
 	}
 
@@ -62,14 +76,24 @@ func AddHistory(c config.SSHConfig) {
 		return
 	}
 
-	err = saveFile(SSHHistory{Connection: c, Date: time.Now()}, list)
+	entry.Date = time.Now()
+	entry.Count = 1
+	for _, item := range list {
+		item.Connection.CleanName()
+		if item.Connection.UniqueKey() == entry.Connection.UniqueKey() {
+			entry.Count = item.Connections() + 1
+			break
+		}
+	}
+	err = saveFile(entry, list)
 	if err != nil {
 		fmt.Println("error saving ggh file")
 		return
 	}
 }
 
-func RemoveByIP(row table.Row) {
+// RemoveByIP removes the entries of every connection to the host ip
+func RemoveByIP(ip string) {
 	list, err := Fetch(getFile())
 
 	if err != nil {
@@ -77,8 +101,6 @@ func RemoveByIP(row table.Row) {
 		return
 	}
 
-	ip := row[1]
-
 	saving := make([]SSHHistory, 0, len(list)-1)
 
 	for _, item := range list {
@@ -96,7 +118,8 @@ func RemoveByIP(row table.Row) {
 
 }
 
-func RemoveByName(row table.Row) {
+// RemoveByName removes the entries of the connections named cName
+func RemoveByName(cName string) {
 	list, err := Fetch(getFile())
 
 	if err != nil {
@@ -104,8 +127,6 @@ func RemoveByName(row table.Row) {
 		return
 	}
 
-	cName := row[0]
-
 	saving := make([]SSHHistory, 0, len(list)-1)
 
 	for _, item := range list {
@@ -154,3 +175,75 @@ func stringify(n SSHHistory, l []SSHHistory) string {
 
 	return string(content)
 }
+
+// sameHost reports whether a and b connect to the same host and port as
+// the same user
+func sameHost(a, b config.SSHConfig) bool {
+	port := func(c config.SSHConfig) string {
+		if c.Port == "" {
+			return "22"
+		}
+		return c.Port
+	}
+	return a.Host == b.Host && a.User == b.User && port(a) == port(b)
+}
+
+// Promote points the history entry of the direct connection c at the
+// config host alias. The entry keeps its date, and an existing entry for
+// alias is merged into it when it reaches the same host. An entry for alias
+// reaching another host is refused, it would be lost.
+func Promote(c config.SSHConfig, alias string) error {
+	c.CleanName()
+	if !c.IsDirectSSH() {
+		return fmt.Errorf("'%s' is not a direct connection", c.Name)
+	}
+
+	list, err := Fetch(getFile())
+	if err != nil {
+		return err
+	}
+
+	promoted := -1
+	for i, item := range list {
+		item.Connection.CleanName()
+		if item.Connection.IsDirectSSH() && item.Connection.UniqueKey() == c.UniqueKey() {
+			promoted = i
+			break
+		}
+	}
+	if promoted < 0 {
+		return fmt.Errorf("no history entry for %s", c.Host)
+	}
+
+	for i, item := range list {
+		if i != promoted && strings.TrimPrefix(item.Connection.Name, config.MissingConfig) == alias && !sameHost(item.Connection, c) {
+			return fmt.Errorf("the history has '%s' for %s already", alias, item.Connection.Host)
+		}
+	}
+
+	entry := list[promoted]
+	entry.Connection.Name = alias
+
+	// The merged entry takes the place of the most recent of the two
+	saving := make([]SSHHistory, 0, len(list))
+	at := -1
+	for i, item := range list {
+		if i != promoted && strings.TrimPrefix(item.Connection.Name, config.MissingConfig) != alias {
+			saving = append(saving, item)
+			continue
+		}
+		if item.Date.After(entry.Date) {
+			entry.Date = item.Date
+		}
+		if i != promoted {
+			entry.Count = entry.Connections() + item.Connections()
+		}
+		if at < 0 {
+			at = len(saving)
+			saving = append(saving, SSHHistory{})
+		}
+	}
+	saving[at] = entry
+
+	return saveFile(SSHHistory{}, saving)
+}
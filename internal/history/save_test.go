@@ -26,3 +26,30 @@ func TestMarshal(t *testing.T) {
 		//t.Errorf("marshal json fail. Got %v, want %v", jsonString, converted)
 	}
 }
+
+func TestAddEntryCount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	AddHistoryFromArgs([]string{"root@10.0.0.5", "-p", "2222"})
+	AddHistoryFromArgs([]string{"deploy@10.0.0.6"})
+	AddHistoryFromArgs([]string{"root@10.0.0.5", "-p", "2222"})
+	AddHistoryFromArgs([]string{"root@10.0.0.5", "-p", "2222"})
+
+	list, err := FetchWithDefaultFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(list))
+	}
+	if list[0].Connection.Host != "10.0.0.5" || list[0].Count != 3 {
+		t.Errorf("first entry = %s counted %d, want 10.0.0.5 counted 3", list[0].Connection.Host, list[0].Count)
+	}
+	if list[1].Count != 1 {
+		t.Errorf("second entry counted %d, want 1", list[1].Count)
+	}
+
+	if (SSHHistory{}).Connections() != 1 {
+		t.Errorf("an entry saved before counting should count once")
+	}
+}
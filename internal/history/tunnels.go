@@ -0,0 +1,63 @@
+package history
+
+import (
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/tunnel"
+)
+
+// DeletedTunnel stands for a tunnel that was applied to a connection and
+// has been deleted since
+const DeletedTunnel = config.MissingConfig + "deleted"
+
+// TunnelNames returns the names of the tunnels applied to the entry, in the
+// order they were applied, with DeletedTunnel for the ones that are gone
+func (h SSHHistory) TunnelNames(all []tunnel.Tunnel) []string {
+	byID := make(map[string]string, len(all))
+	for _, t := range all {
+		byID[t.ID] = t.Name
+	}
+
+	names := make([]string, 0, len(h.Tunnels))
+	for _, id := range h.Tunnels {
+		name, ok := byID[id]
+		if !ok {
+			name = DeletedTunnel
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// SavedTunnels returns the tunnels of the entry that still exist, and how
+// many of them were deleted
+func (h SSHHistory) SavedTunnels(all []tunnel.Tunnel) ([]tunnel.Tunnel, int) {
+	byID := make(map[string]tunnel.Tunnel, len(all))
+	for _, t := range all {
+		byID[t.ID] = t
+	}
+
+	var saved []tunnel.Tunnel
+	for _, id := range h.Tunnels {
+		if t, ok := byID[id]; ok {
+			saved = append(saved, t)
+		}
+	}
+	return saved, len(h.Tunnels) - len(saved)
+}
+
+// tunnelColumn renders the tunnels of the entry for the history table
+func (h SSHHistory) tunnelColumn(all []tunnel.Tunnel) string {
+	return strings.Join(h.TunnelNames(all), ", ")
+}
+
+// loadTunnels returns the saved tunnels, an unreadable tunnels file shows
+// every tunnel of the history as deleted rather than failing the listing
+func loadTunnels() []tunnel.Tunnel {
+	all, err := tunnel.LoadTunnels()
+	if err != nil {
+		return nil
+	}
+	return all
+}
@@ -0,0 +1,163 @@
+package interactive
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// hostRecord is a host of the selectors, the rows are built from it
+type hostRecord struct {
+	Config config.SSHConfig
+	// Entry is the history entry of the host, in the history selector
+	Entry history.SSHHistory
+	// Tunnels are the names of the tunnels last used with the entry
+	Tunnels string
+	Meta    meta.Meta
+	Status  string
+	removed bool
+}
+
+// hostValues return the value of each column for a record
+var hostValues = map[string]func(r hostRecord, now time.Time) string{
+	theme.NameColumn: func(r hostRecord, _ time.Time) string { return r.Config.Name },
+	theme.HostColumn: func(r hostRecord, _ time.Time) string { return r.Config.Host },
+	theme.PortColumn: func(r hostRecord, _ time.Time) string { return r.Config.Port },
+	theme.UserColumn: func(r hostRecord, _ time.Time) string { return r.Config.User },
+	theme.KeyColumn:  func(r hostRecord, _ time.Time) string { return r.Config.Key },
+	theme.LastLoginColumn: func(r hostRecord, now time.Time) string {
+		return history.ReadableTime(now.Sub(r.Entry.Date))
+	},
+	theme.CountColumn:   func(r hostRecord, _ time.Time) string { return strconv.Itoa(r.Entry.Connections()) },
+	theme.TunnelsColumn: func(r hostRecord, _ time.Time) string { return r.Tunnels },
+	theme.ViaColumn:     func(r hostRecord, _ time.Time) string { return r.Entry.Jump },
+	theme.TagsColumn:    func(r hostRecord, _ time.Time) string { return r.Meta.TagsColumn() },
+	theme.NoteColumn:    func(r hostRecord, _ time.Time) string { return r.Meta.Note },
+	theme.StatusColumn:  func(r hostRecord, _ time.Time) string { return r.Status },
+}
+
+// hostOrders compare the records by the columns whose values don't sort,
+// the others are compared by their values
+var hostOrders = map[string]func(a, b hostRecord) int{
+	theme.PortColumn: func(a, b hostRecord) int {
+		pa, _ := strconv.Atoi(a.Config.Port)
+		pb, _ := strconv.Atoi(b.Config.Port)
+		return cmp.Compare(pa, pb)
+	},
+	// the most recent first
+	theme.LastLoginColumn: func(a, b hostRecord) int { return b.Entry.Date.Compare(a.Entry.Date) },
+	theme.CountColumn: func(a, b hostRecord) int {
+		return cmp.Compare(a.Entry.Connections(), b.Entry.Connections())
+	},
+}
+
+// compareHosts orders two records by the column id
+func compareHosts(id string, a, b hostRecord) int {
+	if order, ok := hostOrders[id]; ok {
+		return order(a, b)
+	}
+	var now time.Time
+	return strings.Compare(
+		strings.ToLower(hostValues[id](a, now)),
+		strings.ToLower(hostValues[id](b, now)),
+	)
+}
+
+// hostRow returns the row of the record with the columns ids, the ID of
+// the record goes in a last hidden column
+func hostRow(r hostRecord, id int, ids []string, now time.Time) table.Row {
+	row := make(table.Row, 0, len(ids)+1)
+	for _, column := range ids {
+		row = append(row, hostValues[column](r, now))
+	}
+	return append(row, strconv.Itoa(id))
+}
+
+// rowID returns the ID of the record of a row built by hostRow
+func rowID(row table.Row) int {
+	id, err := strconv.Atoi(row[len(row)-1])
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// tableLayout is the columns and the sort of a host selector
+type tableLayout struct {
+	columns []string
+	// sortBy is the column the rows are sorted by, none when empty
+	sortBy   string
+	sortDesc bool
+}
+
+// activeLayouts are the layouts of the selectors, set by LoadColumns
+var activeLayouts = map[theme.TableStyle]tableLayout{}
+
+// layoutNames are the selectors as named in the settings
+var layoutNames = map[string]theme.TableStyle{
+	"config":  theme.ConfigTable,
+	"history": theme.HistoryTable,
+}
+
+// LoadColumns sets the columns and the sort of the host selectors from the
+// settings, the defaults are kept on errors
+func LoadColumns(tables map[string]settings.Table) error {
+	layouts := map[theme.TableStyle]tableLayout{}
+	for name, t := range tables {
+		what, ok := layoutNames[name]
+		if !ok {
+			return fmt.Errorf("unknown table %q (supported: config, history)", name)
+		}
+		var l tableLayout
+		for _, id := range t.Columns {
+			if err := theme.CheckHostColumn(what, id); err != nil {
+				return fmt.Errorf("table %s: %w", name, err)
+			}
+			l.columns = append(l.columns, id)
+		}
+		if t.Sort != "" {
+			l.sortBy = strings.TrimPrefix(t.Sort, "-")
+			l.sortDesc = strings.HasPrefix(t.Sort, "-")
+			if err := theme.CheckHostColumn(what, l.sortBy); err != nil {
+				return fmt.Errorf("table %s: sort: %w", name, err)
+			}
+		}
+		layouts[what] = l
+	}
+	activeLayouts = layouts
+	return nil
+}
+
+// layoutOf returns the layout of a selector. The status column is only
+// shown while probing, and added to the default columns then.
+func layoutOf(what theme.TableStyle, probing bool) tableLayout {
+	l := activeLayouts[what]
+	columns := l.columns
+	if len(columns) == 0 {
+		columns = theme.DefaultHostColumns(what)
+		if probing {
+			columns = append(columns, theme.StatusColumn)
+		}
+	}
+
+	l.columns = nil
+	for _, id := range columns {
+		if id != theme.StatusColumn || probing {
+			l.columns = append(l.columns, id)
+		}
+	}
+	// a selector showing only the status shows the defaults without probing
+	if len(l.columns) == 0 {
+		l.columns = theme.DefaultHostColumns(what)
+	}
+	return l
+}
@@ -4,37 +4,68 @@ import (
 	"fmt"
 	"github.com/MrLonely14/ggh/internal/config"
 	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/meta"
 	"github.com/MrLonely14/ggh/internal/ssh"
 	"github.com/MrLonely14/ggh/internal/theme"
-	"github.com/charmbracelet/bubbles/table"
+	"github.com/MrLonely14/ggh/internal/tunnel"
 	"log"
 	"os"
-	"time"
+	"slices"
+	"strings"
 )
 
 func Config(value string) []string {
+	c := PickConfig(value)
+	return []string{c.Name}
+}
+
+// PickConfig opens the config selector filtered with value and returns the
+// chosen host, recording it in history
+func PickConfig(value string) config.SSHConfig {
 	list, err := config.ParseWithSearch(value, config.GetConfigFile())
 	if err != nil || len(list) == 0 {
 		fmt.Println("No config found.")
 		os.Exit(0)
 	}
 
-	var rows []table.Row
+	c := Select(configRecords(list), theme.ConfigTable)
+	history.AddHistory(c)
+	return c
+}
+
+// configRecords returns the hosts of the config selector for list, with
+// the tags and note of each host
+func configRecords(list []config.SSHConfig) []hostRecord {
+	store, _ := meta.Load()
+	var records []hostRecord
 	for _, c := range list {
-		rows = append(rows, table.Row{
-			c.Name,
-			c.Host,
-			c.Port,
-			c.User,
-			c.Key,
-		})
+		m := store.Get(c.MetaKey())
+		if c.IsDirectSSH() {
+			c.Name = config.DirectSSH
+		}
+		records = append(records, hostRecord{Config: c, Meta: m})
 	}
-	c := Select(rows, theme.ConfigTable)
-	history.AddHistory(c)
-	return []string{c.Name}
+	return records
+}
+
+// History opens the history selector and returns the ssh arguments of the
+// chosen entry, through its jump hosts if it has some. With offerTunnels the
+// tunnels last used with the entry can be applied again, they are returned
+// when the user agrees. applied are the tunnels already chosen for the
+// connection, they are saved with the entry as the applied again ones are.
+func History(offerTunnels bool, applied []tunnel.Tunnel) ([]string, []tunnel.Tunnel) {
+	entry, reapplied := pickHistory(offerTunnels, applied)
+	return entry.SSHArgs(), reapplied
+}
+
+// PickHistory opens the history selector and returns the chosen host,
+// recording it in history again
+func PickHistory() config.SSHConfig {
+	entry, _ := pickHistory(false, nil)
+	return entry.Connection
 }
 
-func History() []string {
+func pickHistory(offerTunnels bool, applied []tunnel.Tunnel) (history.SSHHistory, []tunnel.Tunnel) {
 	list, err := history.FetchWithDefaultFile()
 
 	if err != nil {
@@ -46,19 +77,48 @@ func History() []string {
 		os.Exit(0)
 	}
 
-	var rows []table.Row
-	currentTime := time.Now()
+	// favorites first, the others keep their order
+	store, _ := meta.Load()
+	slices.SortStableFunc(list, func(a, b history.SSHHistory) int {
+		return cmpFavorite(store.Get(a.Connection.MetaKey()), store.Get(b.Connection.MetaKey()))
+	})
+
+	tunnels, _ := tunnel.LoadTunnels()
+	var records []hostRecord
 	for _, historyItem := range list {
-		rows = append(rows, table.Row{
-			historyItem.Connection.Name,
-			historyItem.Connection.Host,
-			historyItem.Connection.Port,
-			historyItem.Connection.User,
-			historyItem.Connection.Key,
-			fmt.Sprintf("%s", history.ReadableTime(currentTime.Sub(historyItem.Date))),
+		records = append(records, hostRecord{
+			Config:  historyItem.Connection,
+			Entry:   historyItem,
+			Tunnels: strings.Join(historyItem.TunnelNames(tunnels), ", "),
+			Meta:    store.Get(historyItem.Connection.MetaKey()),
 		})
 	}
-	c := Select(rows, theme.HistoryTable)
+	m := run(records, theme.HistoryTable, "", offerTunnels)
+
+	var reapplied []tunnel.Tunnel
+	if m.reapply {
+		reapplied, _ = m.chosen.Entry.SavedTunnels(tunnels)
+	}
+
+	entry := pickedEntry(m.chosen, append(applied, reapplied...))
+	history.AddEntry(entry)
+	return entry, reapplied
+}
+
+// pickedEntry returns the history entry recording the chosen host again,
+// with the tunnels applied to the connection. The arguments it was typed
+// with and the route are kept.
+func pickedEntry(r hostRecord, tunnels []tunnel.Tunnel) history.SSHHistory {
+	entry := r.Entry
+	entry.Connection = r.Config
+	entry.Connection.CleanName()
+	entry.Tunnels = tunnel.IDs(tunnels)
+	return entry
+}
+
+// Search opens the selector over list, already filtered with query
+func Search(list []config.SSHConfig, query string) []string {
+	c := SelectWithFilter(configRecords(list), theme.ConfigTable, query)
 	c.CleanName()
 	history.AddHistory(c)
 	if c.IsDirectSSH() {
@@ -66,3 +126,14 @@ func History() []string {
 	}
 	return []string{c.Name}
 }
+
+// cmpFavorite orders favorites before the other hosts
+func cmpFavorite(a, b meta.Meta) int {
+	switch {
+	case a.Favorite == b.Favorite:
+		return 0
+	case a.Favorite:
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,45 @@
+package interactive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/tunnel"
+)
+
+func TestPickedEntry(t *testing.T) {
+	web := config.SSHConfig{Host: "10.0.0.1", User: "root"}
+
+	tests := []struct {
+		name    string
+		record  hostRecord
+		tunnels []tunnel.Tunnel
+		args    string
+	}{
+		{"typed arguments", hostRecord{Config: web, Entry: history.SSHHistory{
+			Connection: web, Args: []string{"-L", "8080:localhost:80", "root@10.0.0.1"}, Tunnels: []string{"t1"},
+		}}, nil, "-L 8080:localhost:80 root@10.0.0.1"},
+		{"route", hostRecord{Config: config.SSHConfig{Name: "db", Host: "db.internal"}, Entry: history.SSHHistory{
+			Connection: config.SSHConfig{Name: "db", Host: "db.internal"}, Jump: "bastion",
+		}}, []tunnel.Tunnel{{ID: "t2"}, {ID: "t1"}}, "-J bastion db"},
+		{"alias", hostRecord{Config: config.SSHConfig{Name: "stage", Host: "10.0.0.2"}}, nil, "stage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := pickedEntry(tt.record, tt.tunnels)
+			if got := strings.Join(entry.SSHArgs(), " "); got != tt.args {
+				t.Errorf("pickedEntry().SSHArgs() = %q, want %q", got, tt.args)
+			}
+			if entry.Connection.UniqueKey() != tt.record.Config.UniqueKey() {
+				t.Errorf("pickedEntry() connection = %+v, want %+v", entry.Connection, tt.record.Config)
+			}
+			// the entry gets the tunnels applied now, not the ones it had
+			if got, want := strings.Join(entry.Tunnels, ","), strings.Join(tunnel.IDs(tt.tunnels), ","); got != want {
+				t.Errorf("pickedEntry() tunnels = %q, want %q", got, want)
+			}
+		})
+	}
+}
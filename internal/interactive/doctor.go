@@ -0,0 +1,218 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type doctorModel struct {
+	table        table.Model
+	orphans      []history.Orphan
+	actions      map[int]history.RepairAction // Maps orphan index to the chosen action
+	applied      bool
+	exit         bool
+	windowWidth  int
+	windowHeight int
+	tableWidth   int
+	tableHeight  int
+	showHelp     bool
+}
+
+func (m doctorModel) Init() tea.Cmd { return nil }
+
+func (m doctorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.windowWidth = msg.Width
+		m.windowHeight = msg.Height
+
+		w, h, cols := theme.AdjustTableDimensions(
+			m.table.Columns(),
+			m.windowWidth,
+			m.windowHeight,
+		)
+
+		m.tableWidth = w
+		m.tableHeight = h
+
+		m.table.SetColumns(cols)
+		m.table.SetHeight(theme.GetTableHeight(m.tableHeight, len(m.orphans)))
+		m.table.SetWidth(m.tableWidth)
+
+		if settings.Get().Fullscreen {
+			return m, tea.EnterAltScreen
+		}
+
+		return m, tea.ExitAltScreen
+
+	case tea.KeyMsg:
+		if m.showHelp {
+			// any key closes the help
+			m.showHelp = false
+			return m, nil
+		}
+
+		k := activeKeys.Doctor
+		switch {
+		case key.Matches(msg, k.Relink):
+			m.mark(m.table.Cursor(), history.RepairRelink)
+		case key.Matches(msg, k.Direct):
+			m.mark(m.table.Cursor(), history.RepairDirect)
+		case key.Matches(msg, k.Delete):
+			m.mark(m.table.Cursor(), history.RepairDelete)
+		case key.Matches(msg, k.Undo):
+			delete(m.actions, m.table.Cursor())
+		case key.Matches(msg, k.RelinkAll, k.DirectAll, k.DeleteAll):
+			action := history.RepairRelink
+			if key.Matches(msg, k.DirectAll) {
+				action = history.RepairDirect
+			} else if key.Matches(msg, k.DeleteAll) {
+				action = history.RepairDelete
+			}
+			for i := range m.orphans {
+				m.mark(i, action)
+			}
+		case key.Matches(msg, activeKeys.List.Choose):
+			m.applied = true
+			return m, tea.Quit
+		case key.Matches(msg, activeKeys.List.Quit):
+			m.exit = true
+			return m, tea.Quit
+		case key.Matches(msg, activeKeys.List.Help):
+			m.showHelp = true
+			return m, nil
+		default:
+			m.table, cmd = m.table.Update(msg)
+			return m, cmd
+		}
+
+		m.table.SetRows(doctorRows(m.orphans, m.actions))
+		return m, nil
+	}
+
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// mark sets the action of an orphan, relinking needs a suggested host
+func (m *doctorModel) mark(i int, action history.RepairAction) {
+	if i < 0 || i >= len(m.orphans) {
+		return
+	}
+	if action == history.RepairRelink && m.orphans[i].Suggestion.Name == "" {
+		return
+	}
+	m.actions[i] = action
+}
+
+func (m doctorModel) View() string {
+	if m.applied || m.exit {
+		return ""
+	}
+
+	if m.showHelp {
+		return fullHelp(m.keyHelp())
+	}
+
+	help := helpBar(m.keyHelp().short...)
+
+	return theme.BaseStyle.Render(m.table.View()) + "\n " + help
+}
+
+// keyHelp returns the help of the active keys
+func (m doctorModel) keyHelp() helpKeyMap {
+	k := activeKeys
+	apply := k.List.Choose
+	apply.SetHelp(apply.Help().Key, "apply")
+
+	return helpKeyMap{
+		short: []key.Binding{k.Doctor.Relink, k.Doctor.Direct, k.Doctor.Delete, k.Doctor.Undo, apply, k.List.Quit, k.List.Help},
+		full: [][]key.Binding{
+			k.List.navigation(),
+			{k.Doctor.Relink, k.Doctor.Direct, k.Doctor.Delete, k.Doctor.Undo},
+			{k.Doctor.RelinkAll, k.Doctor.DirectAll, k.Doctor.DeleteAll},
+			{apply, k.List.Quit},
+		},
+	}
+}
+
+// Doctor lets the user pick a repair for each orphaned history entry and
+// returns the chosen repairs, or nil when cancelled.
+func Doctor(orphans []history.Orphan) []history.Repair {
+	t := table.New(
+		table.WithColumns(theme.GetColumns(theme.DoctorTable)),
+		table.WithRows(doctorRows(orphans, nil)),
+		table.WithFocused(true),
+		table.WithKeyMap(activeKeys.List.table()),
+	)
+
+	s := table.DefaultStyles()
+	s.Header = theme.HeaderStyle
+	s.Selected = theme.SelectedStyle
+	t.SetStyles(s)
+
+	m := doctorModel{
+		table:   t,
+		orphans: orphans,
+		actions: make(map[int]history.RepairAction),
+	}
+
+	var p *tea.Program
+	if settings.Get().Fullscreen {
+		p = tea.NewProgram(m, tea.WithAltScreen())
+	} else {
+		p = tea.NewProgram(m)
+	}
+
+	finalModel, err := p.Run()
+	if err != nil {
+		fmt.Println("error while running the history doctor, ", err)
+		os.Exit(1)
+	}
+
+	final, ok := finalModel.(doctorModel)
+	if !ok || !final.applied {
+		return nil
+	}
+
+	repairs := make([]history.Repair, 0, len(final.actions))
+	for i, o := range orphans {
+		if action, ok := final.actions[i]; ok {
+			repairs = append(repairs, history.Repair{
+				Alias:  o.Entry.Connection.Name,
+				Action: action,
+				Target: o.Suggestion.Name,
+			})
+		}
+	}
+	return repairs
+}
+
+// doctorRows converts orphans to table rows
+func doctorRows(orphans []history.Orphan, actions map[int]history.RepairAction) []table.Row {
+	rows := make([]table.Row, 0, len(orphans))
+	for i, o := range orphans {
+		suggestion := "-"
+		if o.Suggestion.Name != "" {
+			suggestion = o.Suggestion.Name
+		}
+
+		rows = append(rows, table.Row{
+			o.Entry.Connection.Name,
+			o.Entry.Connection.Host,
+			o.Entry.Connection.User,
+			o.Entry.Connection.Port,
+			suggestion,
+			string(actions[i]),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,199 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type hostFormModel struct {
+	alias      string
+	editing    bool
+	managed    bool
+	focusIndex int
+	inputs     []formInput
+	err        string
+	submitted  bool
+	cancelled  bool
+}
+
+const (
+	inputHostAlias int = iota
+	inputHostName
+	inputHostUser
+	inputHostPort
+	inputHostKey
+)
+
+func newHostForm(c config.SSHConfig, alias string, managed bool) *hostFormModel {
+	editing := alias != ""
+
+	inputs := []formInput{
+		{label: "Alias", placeholder: "my-server", required: true},
+		{label: "HostName", placeholder: "server.example.com", required: true},
+		{label: "User", placeholder: "root"},
+		{label: "Port", placeholder: "22"},
+		{label: "IdentityFile", placeholder: "~/.ssh/id_ed25519"},
+	}
+
+	inputs[inputHostAlias].value = c.Name
+	inputs[inputHostName].value = c.Host
+	inputs[inputHostUser].value = c.User
+	inputs[inputHostPort].value = c.Port
+	inputs[inputHostKey].value = c.Key
+
+	return &hostFormModel{
+		alias:   alias,
+		editing: editing,
+		managed: managed,
+		inputs:  inputs,
+	}
+}
+
+func (m *hostFormModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *hostFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, activeKeys.Form.Cancel):
+			m.cancelled = true
+			return m, tea.Quit
+
+		// checked before next, enter moves to the next field
+		case key.Matches(msg, activeKeys.Form.Submit):
+			return m, m.submit()
+
+		case key.Matches(msg, activeKeys.Form.Next):
+			m.focusIndex = (m.focusIndex + 1) % len(m.inputs)
+			return m, nil
+
+		case key.Matches(msg, activeKeys.Form.Prev):
+			m.focusIndex--
+			if m.focusIndex < 0 {
+				m.focusIndex = len(m.inputs) - 1
+			}
+			return m, nil
+
+		case msg.Type == tea.KeyBackspace:
+			if len(m.inputs[m.focusIndex].value) > 0 {
+				m.inputs[m.focusIndex].value = m.inputs[m.focusIndex].value[:len(m.inputs[m.focusIndex].value)-1]
+			}
+			m.err = ""
+			return m, nil
+
+		default:
+			if len(msg.Runes) > 0 {
+				m.inputs[m.focusIndex].value += string(msg.Runes)
+				m.err = ""
+			}
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m *hostFormModel) View() string {
+	if m.submitted || m.cancelled {
+		return ""
+	}
+
+	var b strings.Builder
+
+	title := "Add Host"
+	if m.editing {
+		title = "Edit Host " + m.alias
+	}
+
+	b.WriteString(theme.FormTitleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		isFocused := i == m.focusIndex
+
+		labelStyle := theme.LabelStyle.Width(15)
+		if input.required {
+			labelStyle = theme.RequiredLabelStyle.Width(15)
+		}
+
+		inputStyle := theme.InputStyle.Width(40)
+		if isFocused {
+			inputStyle = theme.FocusedInputStyle.Width(40)
+		}
+
+		label := labelStyle.Render(input.label + ":")
+
+		value := input.value
+		if value == "" {
+			value = input.placeholder
+			inputStyle = theme.PlaceholderStyle.Width(40)
+		}
+
+		if isFocused {
+			value = value + "▌"
+		}
+
+		b.WriteString(fmt.Sprintf("%s %s\n", label, inputStyle.Render(value)))
+	}
+
+	if m.err != "" {
+		b.WriteString("\n")
+		b.WriteString(theme.FormErrorStyle.Render("Error: " + m.err))
+	}
+
+	b.WriteString(theme.FormHelpStyle.Render("\n" + formHelp()))
+
+	return theme.FormStyle.Render(b.String())
+}
+
+func (m *hostFormModel) submit() tea.Cmd {
+	c := config.SSHConfig{
+		Name: strings.TrimSpace(m.inputs[inputHostAlias].value),
+		Host: strings.TrimSpace(m.inputs[inputHostName].value),
+		User: strings.TrimSpace(m.inputs[inputHostUser].value),
+		Port: strings.TrimSpace(m.inputs[inputHostPort].value),
+		Key:  strings.TrimSpace(m.inputs[inputHostKey].value),
+	}
+
+	var err error
+	if m.editing {
+		err = config.UpdateHost(m.alias, c)
+	} else {
+		err = config.AddHost(c, m.managed)
+	}
+
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+
+	m.submitted = true
+	return tea.Quit
+}
+
+// HostForm shows a form filled with c. It edits the Host block of alias,
+// or adds a new host when alias is empty, in which case managed selects
+// the ggh managed Include file. It returns whether the host was saved.
+func HostForm(c config.SSHConfig, alias string, managed bool) bool {
+	p := tea.NewProgram(newHostForm(c, alias, managed))
+
+	finalModel, err := p.Run()
+	if err != nil {
+		fmt.Println("error while running the host form, ", err)
+		os.Exit(1)
+	}
+
+	if m, ok := finalModel.(*hostFormModel); ok {
+		return m.submitted
+	}
+
+	return false
+}
@@ -0,0 +1,392 @@
+package interactive
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// listKeys are the keys shared by the lists
+type listKeys struct {
+	Up           key.Binding
+	Down         key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
+	Filter       key.Binding
+	StopFilter   key.Binding
+	Choose       key.Binding
+	Quit         key.Binding
+	Fullscreen   key.Binding
+	Help         key.Binding
+}
+
+// hostKeys are the keys of the history and config selectors
+type hostKeys struct {
+	Delete      key.Binding
+	Remove      key.Binding
+	Promote     key.Binding
+	Favorite    key.Binding
+	Tags        key.Binding
+	Note        key.Binding
+	Sort        key.Binding
+	ReverseSort key.Binding
+}
+
+type tunnelKeys struct {
+	New    key.Binding
+	Edit   key.Binding
+	Delete key.Binding
+	Toggle key.Binding
+}
+
+type formKeys struct {
+	Next   key.Binding
+	Prev   key.Binding
+	Submit key.Binding
+	Cancel key.Binding
+}
+
+type doctorKeys struct {
+	Relink    key.Binding
+	Direct    key.Binding
+	Delete    key.Binding
+	Undo      key.Binding
+	RelinkAll key.Binding
+	DirectAll key.Binding
+	DeleteAll key.Binding
+}
+
+type knownHostsKeys struct {
+	Remove      key.Binding
+	RemoveHost  key.Binding
+	RemoveStale key.Binding
+}
+
+type routeKeys struct {
+	Toggle   key.Binding
+	DropLast key.Binding
+}
+
+// keyMap holds the keys of every interactive view
+type keyMap struct {
+	List       listKeys
+	Hosts      hostKeys
+	Tunnels    tunnelKeys
+	Form       formKeys
+	Doctor     doctorKeys
+	KnownHosts knownHostsKeys
+	Route      routeKeys
+}
+
+// action is a binding as named in the settings, with its help
+type action struct {
+	name    string
+	binding *key.Binding
+	desc    string
+}
+
+func (k *keyMap) actions() []action {
+	return []action{
+		{"list.up", &k.List.Up, "up"},
+		{"list.down", &k.List.Down, "down"},
+		{"list.page_up", &k.List.PageUp, "page up"},
+		{"list.page_down", &k.List.PageDown, "page down"},
+		{"list.half_page_up", &k.List.HalfPageUp, "½ page up"},
+		{"list.half_page_down", &k.List.HalfPageDown, "½ page down"},
+		{"list.top", &k.List.Top, "go to start"},
+		{"list.bottom", &k.List.Bottom, "go to end"},
+		{"list.filter", &k.List.Filter, "filter"},
+		{"list.stop_filter", &k.List.StopFilter, "stop filtering"},
+		{"list.choose", &k.List.Choose, "choose"},
+		{"list.quit", &k.List.Quit, "quit"},
+		{"list.fullscreen", &k.List.Fullscreen, "window/full"},
+		{"list.help", &k.List.Help, "more keys"},
+		{"hosts.delete", &k.Hosts.Delete, "delete"},
+		{"hosts.remove", &k.Hosts.Remove, "remove"},
+		{"hosts.promote", &k.Hosts.Promote, "promote"},
+		{"hosts.favorite", &k.Hosts.Favorite, "favorite"},
+		{"hosts.tags", &k.Hosts.Tags, "tags"},
+		{"hosts.note", &k.Hosts.Note, "note"},
+		{"hosts.sort", &k.Hosts.Sort, "sort"},
+		{"hosts.reverse_sort", &k.Hosts.ReverseSort, "reverse sort"},
+		{"tunnels.new", &k.Tunnels.New, "new"},
+		{"tunnels.edit", &k.Tunnels.Edit, "edit"},
+		{"tunnels.delete", &k.Tunnels.Delete, "delete"},
+		{"tunnels.toggle", &k.Tunnels.Toggle, "select"},
+		{"form.next", &k.Form.Next, "next field"},
+		{"form.prev", &k.Form.Prev, "previous field"},
+		{"form.submit", &k.Form.Submit, "save"},
+		{"form.cancel", &k.Form.Cancel, "cancel"},
+		{"doctor.relink", &k.Doctor.Relink, "relink"},
+		{"doctor.direct", &k.Doctor.Direct, "direct"},
+		{"doctor.delete", &k.Doctor.Delete, "delete"},
+		{"doctor.undo", &k.Doctor.Undo, "undo"},
+		{"doctor.relink_all", &k.Doctor.RelinkAll, "relink all"},
+		{"doctor.direct_all", &k.Doctor.DirectAll, "all direct"},
+		{"doctor.delete_all", &k.Doctor.DeleteAll, "delete all"},
+		{"known_hosts.remove", &k.KnownHosts.Remove, "remove entry"},
+		{"known_hosts.remove_host", &k.KnownHosts.RemoveHost, "remove host keys"},
+		{"known_hosts.remove_stale", &k.KnownHosts.RemoveStale, "remove stale"},
+		{"route.toggle", &k.Route.Toggle, "add/remove hop"},
+		{"route.drop_last", &k.Route.DropLast, "drop last"},
+	}
+}
+
+// defaultBindings are the keys of the default preset. The navigation
+// leaves out the keys of the table's own keymap that the views use.
+var defaultBindings = map[string][]string{
+	"list.up":                  {"up", "k"},
+	"list.down":                {"down", "j"},
+	"list.page_up":             {"pgup", "b"},
+	"list.page_down":           {"pgdown"},
+	"list.half_page_up":        {"ctrl+u"},
+	"list.half_page_down":      {"ctrl+d"},
+	"list.top":                 {"home", "g"},
+	"list.bottom":              {"end", "G"},
+	"list.filter":              {"/"},
+	"list.stop_filter":         {"esc", "ctrl+c"},
+	"list.choose":              {"enter"},
+	"list.quit":                {"q", "esc", "ctrl+c"},
+	"list.fullscreen":          {"w"},
+	"list.help":                {"?"},
+	"hosts.delete":             {"d"},
+	"hosts.remove":             {"r"},
+	"hosts.promote":            {"p"},
+	"hosts.favorite":           {"f"},
+	"hosts.tags":               {"t"},
+	"hosts.note":               {"n"},
+	"hosts.sort":               {"s"},
+	"hosts.reverse_sort":       {"S"},
+	"tunnels.new":              {"n"},
+	"tunnels.edit":             {"e"},
+	"tunnels.delete":           {"d"},
+	"tunnels.toggle":           {" "},
+	"form.next":                {"tab", "down", "enter"},
+	"form.prev":                {"shift+tab", "up"},
+	"form.submit":              {"ctrl+s", "alt+enter"},
+	"form.cancel":              {"esc", "ctrl+c"},
+	"doctor.relink":            {"l"},
+	"doctor.direct":            {"c"},
+	"doctor.delete":            {"d"},
+	"doctor.undo":              {"u"},
+	"doctor.relink_all":        {"L"},
+	"doctor.direct_all":        {"C"},
+	"doctor.delete_all":        {"D"},
+	"known_hosts.remove":       {"d"},
+	"known_hosts.remove_host":  {"r"},
+	"known_hosts.remove_stale": {"s"},
+	"route.toggle":             {" "},
+	"route.drop_last":          {"backspace"},
+}
+
+// presets change the default bindings
+var presets = map[string]map[string][]string{
+	"default": {},
+	"vim": {
+		"list.page_up":   {"pgup", "ctrl+b"},
+		"list.page_down": {"pgdown", "ctrl+f"},
+		"form.next":      {"tab", "down", "enter", "ctrl+j"},
+		"form.prev":      {"shift+tab", "up", "ctrl+k"},
+	},
+	"emacs": {
+		"list.up":          {"up", "ctrl+p"},
+		"list.down":        {"down", "ctrl+n"},
+		"list.page_up":     {"pgup", "alt+v"},
+		"list.page_down":   {"pgdown", "ctrl+v"},
+		"list.top":         {"home", "alt+<"},
+		"list.bottom":      {"end", "alt+>"},
+		"list.filter":      {"/", "ctrl+s"},
+		"list.stop_filter": {"esc", "ctrl+c", "ctrl+g"},
+		"list.quit":        {"q", "esc", "ctrl+c", "ctrl+g"},
+		"form.next":        {"tab", "down", "enter", "ctrl+n"},
+		"form.prev":        {"shift+tab", "up", "ctrl+p"},
+		"form.cancel":      {"esc", "ctrl+c", "ctrl+g"},
+	},
+}
+
+// activeKeys are the keys of the views, set by LoadKeys
+var activeKeys = newKeyMap(defaultBindings)
+
+// newKeyMap binds the actions to the keys of bindings
+func newKeyMap(bindings map[string][]string) keyMap {
+	var k keyMap
+	for _, a := range k.actions() {
+		*a.binding = key.NewBinding(
+			key.WithKeys(bindings[a.name]...),
+			key.WithHelp(helpKeys(bindings[a.name]), a.desc),
+		)
+		// an empty list unbinds the action
+		a.binding.SetEnabled(len(bindings[a.name]) > 0)
+	}
+	return k
+}
+
+// LoadKeys sets the keys of the views from the preset and the bindings of
+// the settings, the default keys are kept on errors. It returns a warning
+// for each key bound to two actions of a view.
+func LoadKeys(s settings.Keys) ([]string, error) {
+	preset := s.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	changes, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown key preset %q (supported: default, vim, emacs)", preset)
+	}
+
+	bindings := maps.Clone(defaultBindings)
+	maps.Copy(bindings, changes)
+	for name, keyNames := range s.Bindings {
+		if _, ok := defaultBindings[name]; !ok {
+			return nil, fmt.Errorf("unknown key binding %q", name)
+		}
+		bindings[name] = normalizeKeys(keyNames)
+	}
+
+	activeKeys = newKeyMap(bindings)
+	return conflicts(bindings), nil
+}
+
+// views are the groups of actions whose keys are read together. Stopping
+// the filter isn't in any, its keys are only read while filtering.
+var views = []struct {
+	name    string
+	actions []string
+}{
+	{"hosts", []string{"list.", "hosts."}},
+	{"tunnels", []string{"list.", "tunnels."}},
+	{"form", []string{"form."}},
+	{"doctor", []string{"list.", "doctor."}},
+	{"known_hosts", []string{"list.", "known_hosts."}},
+	{"route", []string{"list.", "route."}},
+}
+
+// conflicts returns the keys bound to two actions of a view, in the order
+// of the actions
+func conflicts(bindings map[string][]string) []string {
+	var warnings []string
+	var k keyMap
+	for _, v := range views {
+		bound := map[string]string{}
+		for _, a := range k.actions() {
+			if a.name == "list.stop_filter" || !slices.ContainsFunc(v.actions, func(prefix string) bool {
+				return strings.HasPrefix(a.name, prefix)
+			}) {
+				continue
+			}
+			for _, name := range bindings[a.name] {
+				if first, ok := bound[name]; ok && first != a.name {
+					warnings = append(warnings, fmt.Sprintf("key %q is bound to both %s and %s in the %s view", name, first, a.name, v.name))
+					continue
+				}
+				bound[name] = a.name
+			}
+		}
+	}
+	return warnings
+}
+
+// normalizeKeys turns the names users write into the names of bubbletea
+func normalizeKeys(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.EqualFold(name, "space") {
+			name = " "
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// helpKeys shows the first two keys of a binding, the way the help bar of
+// bubbles does
+func helpKeys(names []string) string {
+	symbols := map[string]string{"up": "↑", "down": "↓", " ": "space", "pgup": "pgup", "pgdown": "pgdn"}
+	var shown []string
+	for _, name := range names {
+		if s, ok := symbols[name]; ok {
+			name = s
+		}
+		shown = append(shown, name)
+		if len(shown) == 2 {
+			break
+		}
+	}
+	return strings.Join(shown, "/")
+}
+
+// table returns the navigation keys for the bubbles table
+func (k listKeys) table() table.KeyMap {
+	return table.KeyMap{
+		LineUp:       k.Up,
+		LineDown:     k.Down,
+		PageUp:       k.PageUp,
+		PageDown:     k.PageDown,
+		HalfPageUp:   k.HalfPageUp,
+		HalfPageDown: k.HalfPageDown,
+		GotoTop:      k.Top,
+		GotoBottom:   k.Bottom,
+	}
+}
+
+// navigation returns the keys moving in the lists, for the full help
+func (k listKeys) navigation() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.HalfPageUp, k.HalfPageDown, k.Top, k.Bottom}
+}
+
+// helpKeyMap is the help of a view: the bar and the columns of the ? overlay
+type helpKeyMap struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+func (h helpKeyMap) ShortHelp() []key.Binding  { return h.short }
+func (h helpKeyMap) FullHelp() [][]key.Binding { return h.full }
+
+// newHelp returns the help renderer, styled by the theme
+func newHelp() help.Model {
+	h := help.New()
+	h.ShortSeparator = " • "
+	h.Styles.ShortKey = theme.HelpStyle
+	h.Styles.ShortDesc = theme.HelpStyle
+	h.Styles.ShortSeparator = theme.HelpStyle
+	h.Styles.FullKey = theme.PromptStyle
+	h.Styles.FullDesc = theme.HelpStyle
+	h.Styles.FullSeparator = theme.HelpStyle
+	h.Styles.Ellipsis = theme.HelpStyle
+	return h
+}
+
+// helpBar renders the bindings as the one line help of a view
+func helpBar(bindings ...key.Binding) string {
+	return newHelp().ShortHelpView(bindings)
+}
+
+// fullHelp renders the ? overlay of a view
+func fullHelp(h helpKeyMap) string {
+	closeKey := key.NewBinding(key.WithKeys("?"), key.WithHelp("any key", "close"))
+	return theme.BaseStyle.Render(newHelp().FullHelpView(h.FullHelp())) + "\n " + helpBar(closeKey)
+}
+
+// formHelp is the help of the forms, as plain text for the form style
+func formHelp() string {
+	k := activeKeys.Form
+	var blocks []string
+	for _, b := range []key.Binding{k.Next, k.Prev, k.Submit, k.Cancel} {
+		if b.Enabled() {
+			blocks = append(blocks, b.Help().Key+" "+b.Help().Desc)
+		}
+	}
+	return strings.Join(blocks, " • ")
+}
@@ -0,0 +1,84 @@
+package interactive
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestLoadKeys(t *testing.T) {
+	t.Cleanup(func() { activeKeys = newKeyMap(defaultBindings) })
+
+	tests := []struct {
+		name     string
+		keys     settings.Keys
+		pageUp   []string
+		quit     []string
+		favorite []string
+		warnings []string
+		wantErr  bool
+	}{
+		{name: "default", pageUp: []string{"pgup", "b"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{"f"}},
+		{name: "vim", keys: settings.Keys{Preset: "vim"},
+			pageUp: []string{"pgup", "ctrl+b"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{"f"}},
+		{name: "emacs", keys: settings.Keys{Preset: "emacs"},
+			pageUp: []string{"pgup", "alt+v"}, quit: []string{"q", "esc", "ctrl+c", "ctrl+g"}, favorite: []string{"f"}},
+		{name: "bindings over the preset", keys: settings.Keys{Preset: "vim", Bindings: map[string][]string{
+			"list.page_up": {"ctrl+y"}, "hosts.favorite": {"space", "*"},
+		}}, pageUp: []string{"ctrl+y"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{" ", "*"}},
+		{name: "unbound", keys: settings.Keys{Bindings: map[string][]string{"hosts.favorite": {}}},
+			pageUp: []string{"pgup", "b"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{}},
+		{name: "conflict in a view", keys: settings.Keys{Bindings: map[string][]string{"hosts.delete": {"q"}}},
+			pageUp: []string{"pgup", "b"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{"f"},
+			warnings: []string{`key "q" is bound to both list.quit and hosts.delete in the hosts view`}},
+		{name: "conflicts in every view sharing the action", keys: settings.Keys{Bindings: map[string][]string{"list.fullscreen": {"d"}}},
+			pageUp: []string{"pgup", "b"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{"f"},
+			warnings: []string{
+				`key "d" is bound to both list.fullscreen and hosts.delete in the hosts view`,
+				`key "d" is bound to both list.fullscreen and tunnels.delete in the tunnels view`,
+				`key "d" is bound to both list.fullscreen and doctor.delete in the doctor view`,
+				`key "d" is bound to both list.fullscreen and known_hosts.remove in the known_hosts view`,
+			}},
+		{name: "same key in other views", keys: settings.Keys{Bindings: map[string][]string{"tunnels.new": {"f"}}},
+			pageUp: []string{"pgup", "b"}, quit: []string{"q", "esc", "ctrl+c"}, favorite: []string{"f"}},
+		{name: "unknown preset", keys: settings.Keys{Preset: "nano"}, wantErr: true},
+		{name: "unknown binding", keys: settings.Keys{Bindings: map[string][]string{"hosts.connect": {"c"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activeKeys = newKeyMap(defaultBindings)
+			warnings, err := LoadKeys(tt.keys)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("LoadKeys() succeeded")
+				}
+				if !slices.Equal(activeKeys.List.PageUp.Keys(), defaultBindings["list.page_up"]) {
+					t.Errorf("LoadKeys() changed the keys on an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKeys() error = %v", err)
+			}
+
+			if got := activeKeys.List.PageUp.Keys(); !slices.Equal(got, tt.pageUp) {
+				t.Errorf("list.page_up = %q, want %q", got, tt.pageUp)
+			}
+			if got := activeKeys.List.Quit.Keys(); !slices.Equal(got, tt.quit) {
+				t.Errorf("list.quit = %q, want %q", got, tt.quit)
+			}
+			if got := activeKeys.Hosts.Favorite.Keys(); !slices.Equal(got, tt.favorite) {
+				t.Errorf("hosts.favorite = %q, want %q", got, tt.favorite)
+			}
+			if enabled := activeKeys.Hosts.Favorite.Enabled(); enabled != (len(tt.favorite) > 0) {
+				t.Errorf("hosts.favorite enabled = %v", enabled)
+			}
+			if !slices.Equal(warnings, tt.warnings) {
+				t.Errorf("LoadKeys() warnings =\n%s\nwant\n%s", strings.Join(warnings, "\n"), strings.Join(tt.warnings, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/knownhosts"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// knownHostsModel lists the known_hosts entries and removes them
+type knownHostsModel struct {
+	path         string
+	hosts        []config.SSHConfig
+	usages       []knownhosts.Usage
+	table        table.Model
+	filtering    bool
+	filterText   string
+	confirming   string
+	pending      []knownhosts.Entry
+	status       string
+	err          string
+	exit         bool
+	windowWidth  int
+	windowHeight int
+	tableWidth   int
+	tableHeight  int
+	showHelp     bool
+}
+
+func (m knownHostsModel) Init() tea.Cmd { return nil }
+
+func (m knownHostsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.windowWidth = msg.Width
+		m.windowHeight = msg.Height
+
+		w, h, cols := theme.AdjustTableDimensions(
+			m.table.Columns(),
+			m.windowWidth,
+			m.windowHeight,
+		)
+
+		m.tableWidth = w
+		m.tableHeight = h
+
+		m.table.SetColumns(cols)
+		m.resetTableHeight()
+
+		if settings.Get().Fullscreen {
+			return m, tea.EnterAltScreen
+		}
+
+		return m, tea.ExitAltScreen
+
+	case tea.KeyMsg:
+		if m.confirming != "" {
+			return m.updateConfirm(msg)
+		}
+		if m.showHelp {
+			// any key closes the help
+			m.showHelp = false
+			return m, nil
+		}
+		m.err = ""
+
+		if m.filtering {
+			switch msg.Type {
+			case tea.KeyRunes:
+				m.filterText += string(msg.Runes)
+				m.refresh()
+				return m, nil
+			case tea.KeyBackspace:
+				if len(m.filterText) > 0 {
+					m.filterText = m.filterText[:len(m.filterText)-1]
+					m.refresh()
+				}
+				return m, nil
+			}
+		}
+
+		switch {
+		case key.Matches(msg, activeKeys.List.Filter):
+			if !m.filtering {
+				m.filtering = true
+				m.filterText = ""
+				m.refresh()
+			}
+			return m, nil
+		case key.Matches(msg, activeKeys.KnownHosts.Remove):
+			// the selected entry only
+			u, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			m.pending = []knownhosts.Entry{u.Entry}
+			m.confirming = fmt.Sprintf("remove the %s key of %s?", u.KeyType, u.Name())
+			return m, nil
+		case key.Matches(msg, activeKeys.KnownHosts.RemoveHost):
+			// every entry of the hosts using the selected one, as
+			// ssh-keygen -R does for a rebuilt server
+			u, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			if len(u.UsedBy) == 0 {
+				m.err = "no host of yours uses this entry, remove it with " + activeKeys.KnownHosts.Remove.Help().Key
+				return m, nil
+			}
+			m.pending = m.entriesOf(u.UsedBy)
+			m.confirming = fmt.Sprintf("remove the %d keys of %s?", len(m.pending), strings.Join(u.UsedBy, ", "))
+			return m, nil
+		case key.Matches(msg, activeKeys.KnownHosts.RemoveStale):
+			m.pending = nil
+			for _, u := range m.usages {
+				if u.Stale() {
+					m.pending = append(m.pending, u.Entry)
+				}
+			}
+			if len(m.pending) == 0 {
+				m.err = "no stale entries"
+				return m, nil
+			}
+			m.confirming = fmt.Sprintf("remove the %d stale entries?", len(m.pending))
+			return m, nil
+		case m.filtering && key.Matches(msg, activeKeys.List.StopFilter):
+			m.filtering = false
+			m.filterText = ""
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, activeKeys.List.Quit):
+			m.exit = true
+			return m, tea.Quit
+		case key.Matches(msg, activeKeys.List.Help):
+			m.showHelp = true
+			return m, nil
+		}
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// updateConfirm removes the pending entries when the user agrees
+func (m knownHostsModel) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.confirming = ""
+	if msg.String() != "y" && msg.String() != "Y" {
+		m.pending = nil
+		return m, nil
+	}
+
+	backup, err := knownhosts.Remove(m.path, m.pending)
+	m.pending = nil
+	if err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	if err := m.load(); err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	m.status = "removed, the previous file is in " + backup
+	m.refresh()
+	return m, nil
+}
+
+// selected returns the entry of the selected row
+func (m knownHostsModel) selected() (knownhosts.Usage, bool) {
+	row := m.table.SelectedRow()
+	if row == nil {
+		return knownhosts.Usage{}, false
+	}
+	line, _ := strconv.Atoi(row[0])
+	for _, u := range m.usages {
+		if u.Line == line {
+			return u, true
+		}
+	}
+	return knownhosts.Usage{}, false
+}
+
+// entriesOf returns the entries of the hosts named names
+func (m knownHostsModel) entriesOf(names []string) []knownhosts.Entry {
+	var out []knownhosts.Entry
+	for _, u := range m.usages {
+		for _, name := range u.UsedBy {
+			if slices.Contains(names, name) {
+				out = append(out, u.Entry)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// load reads the known_hosts file again
+func (m *knownHostsModel) load() error {
+	entries, err := knownhosts.Load(m.path)
+	if err != nil {
+		return err
+	}
+	m.usages = knownhosts.CrossReference(entries, m.hosts)
+	return nil
+}
+
+// refresh rebuilds the rows, filtered with filterText
+func (m *knownHostsModel) refresh() {
+	rows := make([]table.Row, 0, len(m.usages))
+	for _, u := range m.usages {
+		keyType := u.KeyType
+		if u.Marker == knownhosts.CertAuthority {
+			keyType += " (CA)"
+		}
+		rows = append(rows, table.Row{strconv.Itoa(u.Line), u.Name(), keyType, u.Fingerprint(), u.UsedByColumn()})
+	}
+
+	if m.filtering && m.filterText != "" {
+		var out []table.Row
+		for _, row := range rows {
+			if rowMatches(row[1:], m.filterText) {
+				out = append(out, row)
+			}
+		}
+		rows = out
+	}
+	m.table.SetRows(rows)
+}
+
+func (m knownHostsModel) View() string {
+	if m.exit {
+		return ""
+	}
+	if m.showHelp {
+		return fullHelp(m.keyHelp())
+	}
+	return theme.BaseStyle.Render(m.table.View()) + "\n" + m.HelpView()
+}
+
+// keyHelp returns the help of the active keys
+func (m knownHostsModel) keyHelp() helpKeyMap {
+	k := activeKeys
+	if m.filtering {
+		return helpKeyMap{short: []key.Binding{k.List.StopFilter}}
+	}
+
+	actions := []key.Binding{k.KnownHosts.Remove, k.KnownHosts.RemoveHost, k.KnownHosts.RemoveStale}
+	general := []key.Binding{k.List.Filter, k.List.Quit}
+	return helpKeyMap{
+		short: append(append(actions, general...), k.List.Help),
+		full:  [][]key.Binding{k.List.navigation(), actions, general},
+	}
+}
+
+func (m knownHostsModel) HelpView() string {
+	if m.confirming != "" {
+		return " " + theme.PromptStyle.Render(m.confirming+" (y/N)")
+	}
+
+	line := " " + helpBar(m.keyHelp().short...)
+
+	if m.filtering {
+		line += theme.PromptStyle.Render(" /" + m.filterText)
+	}
+	if m.err != "" {
+		line += " " + theme.ErrorStyle.Render(m.err)
+	} else if m.status != "" {
+		line += "\n " + m.status
+	}
+	return line
+}
+
+func (m *knownHostsModel) resetTableHeight() {
+	m.table.SetHeight(theme.GetTableHeight(
+		m.tableHeight,
+		len(m.table.Rows()),
+	))
+	m.table.SetWidth(m.tableWidth)
+}
+
+// KnownHosts lists the entries of ~/.ssh/known_hosts with the hosts using
+// them, to remove the keys of rebuilt servers and the stale entries
+func KnownHosts() {
+	_m := knownHostsModel{path: knownhosts.Path(), hosts: history.Hosts()}
+	if err := _m.load(); err != nil {
+		fmt.Printf("Error reading %s: %v\n", _m.path, err)
+		os.Exit(1)
+	}
+	if len(_m.usages) == 0 {
+		fmt.Println("No known hosts found.")
+		return
+	}
+
+	t := table.New(
+		table.WithColumns(theme.GetColumns(theme.KnownHostsTable)),
+		table.WithFocused(true),
+		table.WithKeyMap(activeKeys.List.table()),
+	)
+	s := table.DefaultStyles()
+	s.Header = theme.HeaderStyle
+	s.Selected = theme.SelectedStyle
+	t.SetStyles(s)
+	_m.table = t
+	_m.refresh()
+
+	var p *tea.Program
+	if settings.Get().Fullscreen {
+		p = tea.NewProgram(_m, tea.WithAltScreen())
+	} else {
+		p = tea.NewProgram(_m)
+	}
+	if _, err := p.Run(); err != nil {
+		fmt.Println("error while running the known hosts list, ", err)
+		os.Exit(1)
+	}
+}
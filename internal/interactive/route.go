@@ -0,0 +1,303 @@
+package interactive
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/ssh"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// routeModel picks the jump hosts to a target, in the order they are crossed
+type routeModel struct {
+	target       config.SSHConfig
+	hosts        []config.SSHConfig
+	hops         []config.SSHConfig
+	table        table.Model
+	filtering    bool
+	filterText   string
+	err          string
+	done         bool
+	exit         bool
+	windowWidth  int
+	windowHeight int
+	tableWidth   int
+	tableHeight  int
+	showHelp     bool
+}
+
+func (m routeModel) Init() tea.Cmd { return nil }
+
+func (m routeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.windowWidth = msg.Width
+		m.windowHeight = msg.Height
+
+		w, h, cols := theme.AdjustTableDimensions(
+			m.table.Columns(),
+			m.windowWidth,
+			m.windowHeight,
+		)
+
+		m.tableWidth = w
+		m.tableHeight = h
+
+		m.table.SetColumns(cols)
+		m.resetTableHeight()
+
+		if settings.Get().Fullscreen {
+			return m, tea.EnterAltScreen
+		}
+
+		return m, tea.ExitAltScreen
+
+	case tea.KeyMsg:
+		if m.showHelp {
+			// any key closes the help
+			m.showHelp = false
+			return m, nil
+		}
+		m.err = ""
+		if m.filtering {
+			switch msg.Type {
+			case tea.KeyRunes:
+				m.filterText += string(msg.Runes)
+				m.refresh()
+				return m, nil
+			case tea.KeyBackspace:
+				if len(m.filterText) > 0 {
+					m.filterText = m.filterText[:len(m.filterText)-1]
+					m.refresh()
+				}
+				return m, nil
+			}
+		}
+
+		switch {
+		case key.Matches(msg, activeKeys.List.Filter):
+			if !m.filtering {
+				m.filtering = true
+				m.filterText = ""
+				m.refresh()
+			}
+			return m, nil
+		case key.Matches(msg, activeKeys.Route.Toggle):
+			selectedRow := m.table.SelectedRow()
+			if selectedRow == nil {
+				return m, nil
+			}
+			m.toggle(rowHost(selectedRow))
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, activeKeys.Route.DropLast):
+			// drop the last hop
+			if len(m.hops) > 0 {
+				m.hops = m.hops[:len(m.hops)-1]
+				m.refresh()
+			}
+			return m, nil
+		case m.filtering && key.Matches(msg, activeKeys.List.StopFilter):
+			m.filtering = false
+			m.filterText = ""
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, activeKeys.List.Quit):
+			m.exit = true
+			return m, tea.Quit
+		case key.Matches(msg, activeKeys.List.Help):
+			m.showHelp = true
+			return m, nil
+		case key.Matches(msg, activeKeys.List.Choose):
+			if len(m.hops) == 0 {
+				m.err = "pick at least one jump host with " + activeKeys.Route.Toggle.Help().Key
+				return m, nil
+			}
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// toggle adds c as the next hop, or removes it when it already is one
+func (m *routeModel) toggle(c config.SSHConfig) {
+	for i, hop := range m.hops {
+		if hop.UniqueKey() == c.UniqueKey() {
+			m.hops = append(m.hops[:i:i], m.hops[i+1:]...)
+			return
+		}
+	}
+	m.hops = append(m.hops, c)
+}
+
+// refresh rebuilds the rows with the hop numbers, filtered with filterText
+func (m *routeModel) refresh() {
+	rows := make([]table.Row, 0, len(m.hosts))
+	for _, c := range m.hosts {
+		hop := ""
+		for i, h := range m.hops {
+			if h.UniqueKey() == c.UniqueKey() {
+				hop = strconv.Itoa(i + 1)
+			}
+		}
+		name := c.Name
+		if c.IsDirectSSH() {
+			name = config.DirectSSH
+		}
+		rows = append(rows, table.Row{hop, name, c.Host, c.Port, c.User, c.Key})
+	}
+
+	if m.filtering && m.filterText != "" {
+		var out []table.Row
+		for _, row := range rows {
+			if rowMatches(row[1:], m.filterText) {
+				out = append(out, row)
+			}
+		}
+		rows = out
+	}
+	m.table.SetRows(rows)
+}
+
+// rowHost returns the host of a route row
+func rowHost(row table.Row) config.SSHConfig {
+	c := config.SSHConfig{
+		Name: row[1],
+		Host: row[2],
+		Port: row[3],
+		User: row[4],
+		Key:  row[5],
+	}
+	c.CleanName()
+	return c
+}
+
+// command is the ssh command the route ends up running
+func (m routeModel) command() string {
+	e := ssh.Endpoint{SSHConfig: m.target, Jump: ssh.JumpChain(m.hops)}
+	return "ssh " + strings.Join(e.Args(), " ")
+}
+
+func (m routeModel) View() string {
+	if m.done || m.exit {
+		return ""
+	}
+
+	if m.showHelp {
+		return fullHelp(m.keyHelp())
+	}
+
+	title := theme.PromptStyle.Render(" jump hosts to " + ssh.JumpHost(m.target) + ", in order")
+	return title + "\n" + theme.BaseStyle.Render(m.table.View()) + "\n" + m.HelpView()
+}
+
+// keyHelp returns the help of the active keys
+func (m routeModel) keyHelp() helpKeyMap {
+	k := activeKeys
+	if m.filtering {
+		return helpKeyMap{short: []key.Binding{k.List.StopFilter}}
+	}
+
+	connect := k.List.Choose
+	connect.SetHelp(connect.Help().Key, "connect")
+	actions := []key.Binding{k.Route.Toggle, k.Route.DropLast, connect}
+	general := []key.Binding{k.List.Filter, k.List.Quit}
+	return helpKeyMap{
+		short: append(append(actions, general...), k.List.Help),
+		full:  [][]key.Binding{k.List.navigation(), actions, general},
+	}
+}
+
+func (m routeModel) HelpView() string {
+	line := " " + helpBar(m.keyHelp().short...)
+	if m.filtering {
+		line += theme.PromptStyle.Render(" /" + m.filterText)
+	}
+	if m.err != "" {
+		line += " " + theme.ErrorStyle.Render(m.err)
+	}
+
+	preview := "pick the first jump host"
+	if len(m.hops) > 0 {
+		preview = m.command()
+	}
+	return line + "\n " + theme.StrongStyle.Render(preview)
+}
+
+func (m *routeModel) resetTableHeight() {
+	m.table.SetHeight(theme.GetTableHeight(
+		m.tableHeight,
+		len(m.table.Rows()),
+	))
+	m.table.SetWidth(m.tableWidth)
+}
+
+// Route builds a route to a host through jump hosts: the target is picked
+// first, unless given, then the jump hosts in the order they are crossed.
+// The route is saved in history and its ssh arguments are returned.
+func Route(target config.SSHConfig) []string {
+	hosts := history.Hosts()
+	if len(hosts) == 0 {
+		fmt.Println("No hosts found in your ssh config or history.")
+		os.Exit(0)
+	}
+
+	if target.Host == "" {
+		target = Select(configRecords(hosts), theme.ConfigTable)
+		target.CleanName()
+	}
+
+	// the target can't be its own jump host
+	var candidates []config.SSHConfig
+	for _, c := range hosts {
+		if c.UniqueKey() != target.UniqueKey() {
+			candidates = append(candidates, c)
+		}
+	}
+
+	t := table.New(
+		table.WithColumns(theme.GetColumns(theme.RouteTable)),
+		table.WithFocused(true),
+		table.WithKeyMap(activeKeys.List.table()),
+	)
+	s := table.DefaultStyles()
+	s.Header = theme.HeaderStyle
+	s.Selected = theme.SelectedStyle
+	t.SetStyles(s)
+
+	_m := routeModel{target: target, hosts: candidates, table: t}
+	_m.refresh()
+
+	var p *tea.Program
+	if settings.Get().Fullscreen {
+		p = tea.NewProgram(_m, tea.WithAltScreen())
+	} else {
+		p = tea.NewProgram(_m)
+	}
+	res, err := p.Run()
+	if err != nil {
+		fmt.Println("error while running the route builder, ", err)
+		os.Exit(1)
+	}
+
+	m, ok := res.(routeModel)
+	if !ok || !m.done {
+		os.Exit(0)
+	}
+
+	entry := history.SSHHistory{Connection: target, Jump: ssh.JumpChain(m.hops)}
+	history.AddEntry(entry)
+	fmt.Fprintf(os.Stderr, "Connecting with %s\n", m.command())
+	return entry.SSHArgs()
+}
@@ -0,0 +1,69 @@
+package interactive
+
+import (
+	"cmp"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRouteKeys(t *testing.T) {
+	var (
+		down      = tea.KeyMsg{Type: tea.KeyDown}
+		space     = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		backspace = tea.KeyMsg{Type: tea.KeyBackspace}
+		filter    = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")}
+	)
+	hosts := []config.SSHConfig{
+		{Name: "bastion", Host: "bastion.example.com"},
+		{Name: "gw", Host: "gw.example.com"},
+		{Host: "10.0.0.5", Port: "2222", User: "admin"},
+	}
+
+	tests := []struct {
+		name    string
+		keys    []tea.KeyMsg
+		hops    string
+		preview string
+	}{
+		{"no hop", nil, "", "pick the first jump host"},
+		{"one hop", []tea.KeyMsg{space}, "bastion", "ssh -J bastion db"},
+		{"in the order picked", []tea.KeyMsg{down, space, down, space, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp}, space},
+			"gw,10.0.0.5,bastion", "ssh -J gw,admin@10.0.0.5:2222,bastion db"},
+		{"toggled off", []tea.KeyMsg{space, down, space, tea.KeyMsg{Type: tea.KeyUp}, space}, "gw", "ssh -J gw db"},
+		{"drop last", []tea.KeyMsg{space, down, space, backspace}, "bastion", "ssh -J bastion db"},
+		{"drop last without hops", []tea.KeyMsg{backspace}, "", "pick the first jump host"},
+		{"toggle a filtered row", []tea.KeyMsg{filter, {Type: tea.KeyRunes, Runes: []rune("gw")}, space}, "gw", "ssh -J gw db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := routeModel{
+				target: config.SSHConfig{Name: "db", Host: "db.internal"},
+				hosts:  hosts,
+				table:  table.New(table.WithColumns(theme.GetColumns(theme.RouteTable)), table.WithFocused(true)),
+			}
+			m.refresh()
+			for _, msg := range tt.keys {
+				next, _ := m.Update(msg)
+				m = next.(routeModel)
+			}
+
+			// the direct connection has no name, its host shows
+			var hops []string
+			for _, hop := range m.hops {
+				hops = append(hops, cmp.Or(hop.Name, hop.Host))
+			}
+			if got := strings.Join(hops, ","); got != tt.hops {
+				t.Errorf("hops = %q, want %q", got, tt.hops)
+			}
+			if view := m.HelpView(); !strings.Contains(view, tt.preview) {
+				t.Errorf("preview of %q = %q, want %q", tt.hops, view, tt.preview)
+			}
+		})
+	}
+}
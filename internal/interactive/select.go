@@ -4,23 +4,43 @@ import (
 	"fmt"
 	"github.com/MrLonely14/ggh/internal/config"
 	"github.com/MrLonely14/ggh/internal/history"
+	"github.com/MrLonely14/ggh/internal/keys"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/probe"
 	"github.com/MrLonely14/ggh/internal/settings"
 	"github.com/MrLonely14/ggh/internal/theme"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type model struct {
-	table        table.Model
-	allRows      []table.Row
+	what   theme.TableStyle
+	prober *probe.Prober
+	table  table.Model
+	// records are the hosts listed, the rows carry their index as ID
+	records      []hostRecord
+	layout       tableLayout
 	filteredRows []table.Row
 	filtering    bool
 	filterText   string
-	choice       config.SSHConfig
+	promoting    bool
+	promoteText  string
+	editing      string
+	editText     string
+	showHelp     bool
+	offerTunnels bool
+	keyWarnings  map[string]string
+	confirming   bool
+	reapply      bool
+	err          string
+	chosen       hostRecord
 	exit         bool
 	windowWidth  int
 	windowHeight int
@@ -28,27 +48,45 @@ type model struct {
 	tableHeight  int
 }
 
-func (m model) Init() tea.Cmd { return nil }
+// probeMsg carries the result of probing one host
+type probeMsg probe.Result
+
+func (m model) Init() tea.Cmd {
+	if m.prober == nil {
+		return nil
+	}
+
+	// probe every address once, the ones cached already have their status
+	var cmds []tea.Cmd
+	seen := map[string]bool{}
+	for _, r := range m.records {
+		addr := recordAddr(r)
+		if seen[addr] || r.Status != probe.Pending {
+			continue
+		}
+		seen[addr] = true
+		cmds = append(cmds, func() tea.Msg {
+			return probeMsg(m.prober.Probe(addr))
+		})
+	}
+	return tea.Batch(cmds...)
+}
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case probeMsg:
+		setStatus(m.records, probe.Result(msg))
+		m.refresh()
+		return m, nil
+
 	// 1. Handle window resize events
 	case tea.WindowSizeMsg:
 		m.windowWidth = msg.Width
 		m.windowHeight = msg.Height
 
-		w, h, cols := theme.AdjustTableDimensions(
-			m.table.Columns(),
-			m.windowWidth,
-			m.windowHeight,
-		)
-
-		m.tableWidth = w
-		m.tableHeight = h
-
 		// Apply the new widths
-		m.table.SetColumns(cols)
+		m.resetColumns()
 		m.resetTableHeight()
 
 		if settings.Get().Fullscreen {
@@ -58,89 +96,142 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.ExitAltScreen
 
 	case tea.KeyMsg:
+		if m.promoting {
+			return m.updatePromote(msg)
+		}
+		if m.editing != "" {
+			return m.updateEdit(msg)
+		}
+		if m.confirming {
+			return m.updateConfirmTunnels(msg)
+		}
+		if m.showHelp {
+			// any key closes the help
+			m.showHelp = false
+			return m, nil
+		}
+		m.err = ""
+
 		if m.filtering {
 			switch msg.Type {
 			case tea.KeyRunes:
 				// Add the typed character to the filter text
 				m.filterText += string(msg.Runes)
-				m.applyFilter()
+				m.refresh()
 				return m, nil
 			case tea.KeyBackspace:
 				// Remove the last character from the filter text
 				if len(m.filterText) > 0 {
 					m.filterText = m.filterText[:len(m.filterText)-1]
-					m.applyFilter()
+					m.refresh()
 				}
 				return m, nil
 			default:
 				// any other keys, pass to the table
 			}
 		}
-		switch msg.String() {
-		case "/":
+		switch {
+		case key.Matches(msg, activeKeys.List.Filter):
 			if !m.filtering {
 				m.filtering = true
 				m.filterText = ""
-				m.applyFilter()
+				m.refresh()
 				return m, nil
 			}
 			// If we are already filtering, we don't want to do anything
 			return m, nil
-		case "d":
-			selectedRow := m.table.SelectedRow()
+		case key.Matches(msg, activeKeys.Hosts.Delete):
+			selected, ok := m.selected()
 			// guard against selection nil
-			if selectedRow == nil {
+			if !ok {
 				return m, nil
 			}
-			history.RemoveByIP(selectedRow)
+			history.RemoveByIP(selected.Config.Host)
 
-			// Filter out the selected row and all rows with the same IP/host
-			rows := []table.Row{}
-			for _, row := range m.table.Rows() {
-				if row[1] != selectedRow[1] {
-					rows = append(rows, row)
+			// Filter out the selected host and all hosts with the same IP/host
+			host := selected.Config.Host
+			for i := range m.records {
+				if m.records[i].Config.Host == host {
+					m.records[i].removed = true
 				}
 			}
-			m.allRows = rows
-			m.table.SetRows(m.allRows)
-
-			m.table, cmd = m.table.Update("") // Overrides default `d` behavior
-
-			// check if the table is empty
-			if len(m.table.Rows()) == 0 {
-				m.exit = true
-				return m, tea.Quit
-			}
-
-			return m, cmd
-		case "r":
-			selectedRow := m.table.SelectedRow()
+			return m.afterRemove()
+		case key.Matches(msg, activeKeys.Hosts.Remove):
+			selected, ok := m.selected()
 			// guard against selection nil
-			if selectedRow == nil {
+			if !ok {
 				return m, nil
 			}
-			history.RemoveByName(selectedRow)
+			history.RemoveByName(selected.Config.Name)
 
-			// Filter out the selected row
-			rows := []table.Row{}
-			for _, row := range m.table.Rows() {
-				if row[0] != selectedRow[0] {
-					rows = append(rows, row)
+			// Filter out the hosts of the same name
+			name := selected.Config.Name
+			for i := range m.records {
+				if m.records[i].Config.Name == name {
+					m.records[i].removed = true
 				}
 			}
-			m.allRows = rows
-			m.table.SetRows(m.allRows)
-
-			m.table, cmd = m.table.Update("")
-
-			// check if the table is empty
-			if len(m.table.Rows()) == 0 {
-				m.exit = true
-				return m, tea.Quit
+			return m.afterRemove()
+		case key.Matches(msg, activeKeys.Hosts.Promote):
+			selected, ok := m.selected()
+			// only direct connections from history can be promoted
+			if !ok || m.what != theme.HistoryTable || selected.Config.Name != config.DirectSSH {
+				return m, nil
 			}
-
-			return m, cmd
-		case "w":
+			m.promoting = true
+			m.promoteText = ""
+			return m, nil
+		case key.Matches(msg, activeKeys.Hosts.Favorite):
+			selected, ok := m.selected()
+			if !ok || !m.hasMeta() {
+				return m, nil
+			}
+			metaKey := selected.Config.MetaKey()
+			store, err := meta.Load()
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			hm := store.Get(metaKey)
+			hm.Favorite = !hm.Favorite
+			store.Set(metaKey, hm)
+			if err := store.Save(); err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			m.setMeta(metaKey, hm)
+			return m, nil
+		case key.Matches(msg, activeKeys.Hosts.Tags, activeKeys.Hosts.Note):
+			selected, ok := m.selected()
+			if !ok || !m.hasMeta() {
+				return m, nil
+			}
+			store, err := meta.Load()
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			// start from the current value
+			hm := store.Get(selected.Config.MetaKey())
+			m.editing = "note"
+			m.editText = hm.Note
+			if key.Matches(msg, activeKeys.Hosts.Tags) {
+				m.editing = "tags"
+				m.editText = strings.Join(hm.Tags, ", ")
+			}
+			return m, nil
+		case key.Matches(msg, activeKeys.Hosts.Sort):
+			m.nextSort()
+			return m, nil
+		case key.Matches(msg, activeKeys.Hosts.ReverseSort):
+			if m.layout.sortBy == "" {
+				m.layout.sortBy = m.layout.columns[0]
+			}
+			m.layout.sortDesc = !m.layout.sortDesc
+			m.resetColumns()
+			m.refresh()
+			return m, nil
+		case key.Matches(msg, activeKeys.List.Fullscreen):
 			// toggle fullscreen mode
 			newsettings := settings.Get()
 			newsettings.Fullscreen = !newsettings.Fullscreen
@@ -156,20 +247,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// If we can't save the settings, do nothing
 			return m, nil
-		case "q", "ctrl+c", "esc":
-			if m.filtering {
-				m.stopFiltering()
-				return m, nil
-			}
+		case m.filtering && key.Matches(msg, activeKeys.List.StopFilter):
+			m.stopFiltering()
+			return m, nil
+		case key.Matches(msg, activeKeys.List.Quit):
 			m.exit = true
 			return m, tea.Quit
-		case "enter":
-			selectedRow := m.table.SelectedRow()
+		case key.Matches(msg, activeKeys.List.Help):
+			m.showHelp = true
+			return m, nil
+		case key.Matches(msg, activeKeys.List.Choose):
+			selected, ok := m.selected()
 			// guard against selection nil
-			if selectedRow == nil {
+			if !ok {
+				return m, nil
+			}
+			// ask before opening the tunnels last used with the connection
+			if m.offerTunnels && m.what == theme.HistoryTable && selected.Tunnels != "" {
+				m.confirming = true
 				return m, nil
 			}
-			m.choice = setConfig(selectedRow)
+			m.chosen = *selected
 			return m, tea.Quit
 		}
 	}
@@ -177,46 +275,301 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-// applyFilter re-filters the "allRows" into "filteredRows" based on m.filterText
-func (m *model) applyFilter() {
-	if m.filterText == "" {
-		// no filter → show all
-		m.filteredRows = m.allRows
-	} else {
-		var out []table.Row
-		lowerFilter := strings.ToLower(m.filterText)
-		for _, row := range m.allRows {
-			// For example, we check row[0] or row[1], or all fields
-			rowStr := strings.ToLower(strings.Join(row, " "))
-			if strings.Contains(rowStr, lowerFilter) {
-				out = append(out, row)
+// afterRemove shows the hosts left after removing some, exiting when there
+// are none
+func (m model) afterRemove() (tea.Model, tea.Cmd) {
+	m.refresh()
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update("") // Overrides the table keys
+
+	// check if the list is empty
+	if !slices.ContainsFunc(m.records, func(r hostRecord) bool { return !r.removed }) {
+		m.exit = true
+		return m, tea.Quit
+	}
+
+	return m, cmd
+}
+
+// nextSort sorts by the column after the one sorted by, and keeps the
+// order of the list after the last column
+func (m *model) nextSort() {
+	i := slices.Index(m.layout.columns, m.layout.sortBy)
+	m.layout.sortBy = ""
+	m.layout.sortDesc = false
+	if i+1 < len(m.layout.columns) {
+		m.layout.sortBy = m.layout.columns[i+1]
+	}
+	m.resetColumns()
+	m.refresh()
+}
+
+// updateConfirmTunnels asks whether the tunnels of the selected history
+// entry should be applied again
+func (m model) updateConfirmTunnels(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		m.reapply = true
+	case "n", "N":
+		m.reapply = false
+	case "esc", "ctrl+c", "q":
+		m.confirming = false
+		return m, nil
+	default:
+		return m, nil
+	}
+	m.confirming = false
+	if selected, ok := m.selected(); ok {
+		m.chosen = *selected
+	}
+	return m, tea.Quit
+}
+
+// updatePromote reads the alias for the selected direct connection, then
+// saves it as a config host and points its history entry at the alias
+func (m model) updatePromote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyRunes:
+		m.promoteText += string(msg.Runes)
+	case tea.KeyBackspace:
+		if len(m.promoteText) > 0 {
+			m.promoteText = m.promoteText[:len(m.promoteText)-1]
+		}
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.promoting = false
+	case tea.KeyEnter:
+		m.promoting = false
+		selected, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+
+		c := selected.Config
+		c.CleanName()
+		promoted := c
+		promoted.Name = strings.TrimSpace(m.promoteText)
+
+		if err := config.AddHost(promoted, false); err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		if err := history.Promote(c, promoted.Name); err != nil {
+			// without its history entry the host isn't promoted, don't
+			// leave it half done
+			if rmErr := config.RemoveHost(promoted.Name); rmErr != nil {
+				err = fmt.Errorf("%w, and %s stays in the ssh config: %v", err, promoted.Name, rmErr)
 			}
+			m.err = err.Error()
+			return m, nil
+		}
+		// the tags and note follow the host to its alias
+		if store, err := meta.Load(); err == nil {
+			store.Move(c.MetaKey(), promoted.MetaKey())
+			_ = store.Save()
+		}
+
+		selected.Config.Name = promoted.Name
+		selected.Entry.Connection.Name = promoted.Name
+		m.refresh()
+	}
+	return m, nil
+}
+
+// updateEdit reads the tags or the note of the selected host, then saves
+// them in the ggh metadata file
+func (m model) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		m.editText += string(msg.Runes)
+	case tea.KeyBackspace:
+		if len(m.editText) > 0 {
+			m.editText = m.editText[:len(m.editText)-1]
+		}
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.editing = ""
+	case tea.KeyEnter:
+		editing := m.editing
+		m.editing = ""
+		selected, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+
+		metaKey := selected.Config.MetaKey()
+		store, err := meta.Load()
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		hm := store.Get(metaKey)
+		if editing == "tags" {
+			hm.Tags = meta.ParseTags(m.editText)
+		} else {
+			hm.Note = strings.TrimSpace(m.editText)
+		}
+		store.Set(metaKey, hm)
+		if err := store.Save(); err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		m.setMeta(metaKey, hm)
+	}
+	return m, nil
+}
+
+// hasMeta reports whether the rows are hosts with tags and a note
+func (m model) hasMeta() bool {
+	return m.what == theme.ConfigTable || m.what == theme.HistoryTable
+}
+
+// setMeta shows hm in the rows of the host with key
+func (m *model) setMeta(metaKey string, hm meta.Meta) {
+	for i := range m.records {
+		if m.records[i].Config.MetaKey() == metaKey {
+			m.records[i].Meta = hm
+		}
+	}
+	m.refresh()
+}
+
+// selected returns the record of the selected row
+func (m model) selected() (*hostRecord, bool) {
+	row := m.table.SelectedRow()
+	if row == nil {
+		return nil, false
+	}
+	id := rowID(row)
+	if id < 0 || id >= len(m.records) {
+		return nil, false
+	}
+	return &m.records[id], true
+}
+
+// refresh rebuilds the rows from the records left, the ones containing
+// m.filterText in one of their columns, then sorted by the column chosen
+func (m *model) refresh() {
+	now := time.Now()
+	var ids []int
+	var rows []table.Row
+	for i, r := range m.records {
+		if r.removed {
+			continue
+		}
+		row := hostRow(r, i, m.layout.columns, now)
+		if rowMatches(row[:len(row)-1], m.filterText) { // not the ID
+			ids = append(ids, i)
+			rows = append(rows, row)
+		}
+	}
+
+	if by := m.layout.sortBy; by != "" {
+		order := make([]int, len(ids))
+		for i := range order {
+			order[i] = i
 		}
-		m.filteredRows = out
+		slices.SortStableFunc(order, func(a, b int) int {
+			c := compareHosts(by, m.records[ids[a]], m.records[ids[b]])
+			if m.layout.sortDesc {
+				return -c
+			}
+			return c
+		})
+		rows = pick(rows, order)
 	}
+
+	m.filteredRows = rows
 	m.table.SetRows(m.filteredRows)
 }
 
+// rowMatches reports whether the columns of a row contain filter, ignoring
+// case. The filter of every selector works this way.
+func rowMatches(row []string, filter string) bool {
+	return strings.Contains(strings.ToLower(strings.Join(row, " ")), strings.ToLower(filter))
+}
+
+// pick returns the elements of s at the indices
+func pick[T any](s []T, indices []int) []T {
+	out := make([]T, 0, len(indices))
+	for _, i := range indices {
+		out = append(out, s[i])
+	}
+	return out
+}
+
 // stopFiltering leaves filtering mode & restores all data
 func (m *model) stopFiltering() {
 	m.filtering = false
 	m.filterText = ""
-	m.filteredRows = m.allRows
-	m.table.SetRows(m.filteredRows)
+	m.refresh()
+}
+
+// resetColumns sizes the columns to the window, the sorted one marked
+func (m *model) resetColumns() {
+	w, h, cols := theme.AdjustHostColumns(
+		m.what,
+		m.layout.columns,
+		m.windowWidth,
+		m.windowHeight,
+	)
+	m.tableWidth = w
+	m.tableHeight = h
+
+	if i := slices.Index(m.layout.columns, m.layout.sortBy); i >= 0 {
+		arrow := " ▲"
+		if m.layout.sortDesc {
+			arrow = " ▼"
+		}
+		cols[i].Title += arrow
+	}
+	// the ID of the records is never shown
+	m.table.SetColumns(append(cols, table.Column{}))
 }
 
-func setConfig(row table.Row) config.SSHConfig {
-	return config.SSHConfig{
-		Name: row[0],
-		Host: row[1],
-		Port: row[2],
-		User: row[3],
-		Key:  row[4],
+// recordAddr returns the address probed for a host
+func recordAddr(r hostRecord) string {
+	host := r.Config.Host
+	if host == "" {
+		host = r.Config.Name
 	}
+	return probe.Addr(host, r.Config.Port)
+}
+
+// setStatus fills the status of the records probed by r
+func setStatus(records []hostRecord, r probe.Result) {
+	for i := range records {
+		if recordAddr(records[i]) == r.Addr {
+			records[i].Status = r.Status()
+		}
+	}
+}
+
+// withStatus fills the status of the records, cached results are filled
+// in and the others are pending
+func withStatus(records []hostRecord, prober *probe.Prober) {
+	for i := range records {
+		records[i].Status = probe.Pending
+		if r, ok := prober.Cached(recordAddr(records[i])); ok {
+			records[i].Status = r.Status()
+		}
+	}
+}
+
+// keyWarnings checks the keys of the hosts once each, for the help bar
+func keyWarnings(records []hostRecord) map[string]string {
+	warnings := map[string]string{}
+	for _, r := range records {
+		path := r.Config.Key
+		if _, done := warnings[path]; done || path == "" {
+			continue
+		}
+		warnings[path] = strings.Join(keys.Check(path), ", ")
+	}
+	return warnings
 }
 
 func (m model) View() string {
-	if m.choice.Host != "" || m.exit {
+	if m.chosen.Config.Host != "" || m.exit {
 		return ""
 	}
 
@@ -227,9 +580,7 @@ func (m model) View() string {
 		)
 
 		// Style: high-contrast foreground, rounded border, padded.
-		styled := lipgloss.NewStyle().
-			Foreground(lipgloss.AdaptiveColor{Light: "#FF0060", Dark: "#FF79C6"}).
-			Render(msg)
+		styled := theme.ErrorStyle.Render(msg)
 
 		// Center horizontally by calculating left padding.
 		pad := max((m.windowWidth-lipgloss.Width(styled))/2, 0)
@@ -238,42 +589,77 @@ func (m model) View() string {
 		return centered
 	}
 
+	if m.showHelp {
+		return fullHelp(m.keyHelp())
+	}
+
 	return theme.BaseStyle.Render(m.table.View()) + "\n" + m.HelpView() // ← exactly one row
 }
 
-func (m model) HelpView() string {
-	var blocks []string
-	km := table.DefaultKeyMap()
+// keyHelp returns the help of the active keys
+func (m model) keyHelp() helpKeyMap {
+	k := activeKeys
 	if m.filtering {
-		blocks = append(blocks, "esc quit • ")
-	} else {
+		return helpKeyMap{short: []key.Binding{k.List.StopFilter}}
+	}
 
-		blocks = append(blocks,
-			fmt.Sprintf("%s %s", km.LineUp.Help().Key, km.LineUp.Help().Desc),
-			fmt.Sprintf("%s %s", km.LineDown.Help().Key, km.LineDown.Help().Desc),
-		)
+	var actions []key.Binding
+	if m.what == theme.HistoryTable {
+		actions = append(actions, k.Hosts.Delete, k.Hosts.Remove, k.Hosts.Promote)
+	}
+	if m.hasMeta() {
+		actions = append(actions, k.Hosts.Favorite, k.Hosts.Tags, k.Hosts.Note)
+	}
+	sorting := []key.Binding{k.Hosts.Sort, k.Hosts.ReverseSort}
+	general := []key.Binding{k.List.Choose, k.List.Fullscreen, k.List.Filter, k.List.Quit}
+
+	short := append([]key.Binding{k.List.Up, k.List.Down}, actions...)
+	short = append(short, k.Hosts.Sort, k.List.Fullscreen, k.List.Filter, k.List.Quit, k.List.Help)
+	return helpKeyMap{
+		short: short,
+		full:  [][]key.Binding{k.List.navigation(), actions, sorting, general},
+	}
+}
+
+func (m model) HelpView() string {
+	help := helpBar(m.keyHelp().short...)
+
+	if m.promoting {
+		prompt := theme.PromptStyle.Render("alias for this host: " + m.promoteText)
+		return " " + prompt
+	}
 
-		if len(m.table.Columns()) == 6 {
-			blocks = append(blocks, "d delete", "r remove")
+	if m.editing != "" {
+		label := "note: "
+		if m.editing == "tags" {
+			label = "tags, separated by commas: "
 		}
+		prompt := theme.PromptStyle.Render(label + m.editText)
+		return " " + prompt
+	}
 
-		blocks = append(blocks,
-			"w window/full",
-			"/ filter",
-			"q quit",
-		)
+	if selected, ok := m.selected(); ok && m.confirming {
+		question := "re-apply tunnels " + selected.Tunnels + "? (Y/n)"
+		if strings.Contains(selected.Tunnels, history.DeletedTunnel) {
+			question += " deleted tunnels are skipped"
+		}
+		prompt := theme.PromptStyle.Render(question)
+		return " " + prompt
 	}
 
-	// join with “ • ” and colour once
-	help := lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#B2B2B2", Dark: "#4A4A4A"}).
-		Render(strings.Join(blocks, " • "))
+	if m.err != "" {
+		errMsg := theme.ErrorStyle.Render(m.err)
+		return " " + help + " " + errMsg
+	}
+
+	if selected, ok := m.selected(); ok && !m.filtering && m.keyWarnings[selected.Config.Key] != "" {
+		warning := theme.ErrorStyle.Render("⚠ " + m.keyWarnings[selected.Config.Key])
+		return " " + help + "\n " + warning
+	}
 
 	if m.filtering {
-		prompt := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("57")).
-			Render("/" + m.filterText)
-		return " " + help + prompt
+		prompt := theme.PromptStyle.Render("/" + m.filterText)
+		return " " + help + theme.HelpStyle.Render(" • ") + prompt
 	}
 	return " " + help
 }
@@ -286,23 +672,53 @@ func (m *model) resetTableHeight() {
 	m.table.SetWidth(m.tableWidth)
 }
 
-func Select(rows []table.Row, what theme.TableStyle) config.SSHConfig {
+// Select opens the selector over the hosts and returns the chosen one
+func Select(records []hostRecord, what theme.TableStyle) config.SSHConfig {
+	return SelectWithFilter(records, what, "")
+}
+
+// SelectWithFilter opens the selector already filtering with filter
+func SelectWithFilter(records []hostRecord, what theme.TableStyle, filter string) config.SSHConfig {
+	return run(records, what, filter, false).chosen.Config
+}
+
+// run shows the selector until a host is chosen, exiting when it is quit
+func run(records []hostRecord, what theme.TableStyle, filter string, offerTunnels bool) model {
+	// probe the hosts in the background when enabled in the settings and
+	// the status is shown
+	s := settings.Get().Probe
+	layout := layoutOf(what, s.Enabled)
+	var prober *probe.Prober
+	if slices.Contains(layout.columns, theme.StatusColumn) {
+		prober = probe.New(s)
+		withStatus(records, prober)
+	}
+
 	t := table.New(
-		table.WithColumns(theme.GetColumns(what)),
-		table.WithRows(rows),
 		table.WithFocused(true),
+		table.WithKeyMap(activeKeys.List.table()),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = theme.HeaderStyle
-	s.Selected = theme.SelectedStyle
+	styles := table.DefaultStyles()
+	styles.Header = theme.HeaderStyle
+	styles.Selected = theme.SelectedStyle
 
-	t.SetStyles(s)
+	t.SetStyles(styles)
 	_m := model{
+		what:         what,
+		prober:       prober,
 		table:        t,
-		allRows:      rows,
-		filteredRows: rows,
+		records:      records,
+		layout:       layout,
+		offerTunnels: offerTunnels,
+		keyWarnings:  keyWarnings(records),
 	}
+	_m.resetColumns()
+	if filter != "" {
+		_m.filtering = true
+		_m.filterText = filter
+	}
+	_m.refresh()
 
 	var p *tea.Program
 	if settings.Get().Fullscreen {
@@ -315,15 +731,18 @@ func Select(rows []table.Row, what theme.TableStyle) config.SSHConfig {
 		fmt.Println("error while running the interactive selector, ", err)
 		os.Exit(1)
 	}
+	if prober != nil {
+		_ = prober.Save()
+	}
 	// Assert the final tea.Model to our local model and print the choice.
 	if m, ok := m.(model); ok {
-		if m.choice.Host != "" {
-			return m.choice
+		if m.chosen.Config.Host != "" {
+			return m
 		}
 		if m.exit {
 			os.Exit(0)
 		}
 	}
 
-	return config.SSHConfig{}
+	return model{}
 }
@@ -0,0 +1,87 @@
+package interactive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRefreshFilter(t *testing.T) {
+	records := []hostRecord{
+		{Config: config.SSHConfig{Name: "prod-db", Host: "10.0.0.1"}},
+		{Config: config.SSHConfig{Name: "prod-web", Host: "10.0.0.2"}},
+		{Config: config.SSHConfig{Name: "stage", Host: "stage.example.com"}, Meta: meta.Meta{Note: "Primary DB"}},
+		{Config: config.SSHConfig{Name: "old-db", Host: "10.0.0.9"}, removed: true},
+	}
+
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"", "prod-db,prod-web,stage"},
+		{"prod", "prod-db,prod-web"},
+		{"DB", "prod-db,stage"},
+		{"0.0.2", "prod-web"},
+		// the columns are matched as written, not loosely
+		{"pdb", ""},
+		{"stg", ""},
+	}
+	columns := []string{theme.NameColumn, theme.HostColumn, theme.NoteColumn}
+	var cols []table.Column
+	for _, id := range columns {
+		cols = append(cols, table.Column{Title: theme.HostColumnTitle(id), Width: 10})
+	}
+	// the hidden ID column
+	cols = append(cols, table.Column{})
+
+	for _, tt := range tests {
+		m := model{
+			records:    records,
+			layout:     tableLayout{columns: columns},
+			filterText: tt.filter,
+			table:      table.New(table.WithColumns(cols)),
+		}
+		m.refresh()
+
+		var names []string
+		for _, row := range m.filteredRows {
+			names = append(names, row[0])
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("filter %q = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestPromoteFailureRemovesHost(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// the direct connection has no history entry to point at the alias
+	m := model{
+		records:     []hostRecord{{Config: config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.5", User: "root"}}},
+		layout:      tableLayout{columns: []string{theme.NameColumn}},
+		table:       table.New(table.WithColumns([]table.Column{{Title: "Name", Width: 10}, {}})),
+		promoting:   true,
+		promoteText: "db",
+	}
+	m.refresh()
+
+	updated, _ := m.updatePromote(tea.KeyMsg{Type: tea.KeyEnter})
+	if updated.(model).err == "" {
+		t.Errorf("updatePromote() without a history entry should fail")
+	}
+	if c, _ := config.GetConfig("db"); c.Name != "" {
+		t.Errorf("the failed promotion left %+v in the ssh config", c)
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/MrLonely14/ggh/internal/theme"
 	"github.com/MrLonely14/ggh/internal/tunnel"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 type tunnelFormModel struct {
@@ -74,35 +75,27 @@ func (m *tunnelFormModel) Init() tea.Cmd {
 func (m *tunnelFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "esc":
+		switch {
+		case key.Matches(msg, activeKeys.Form.Cancel):
 			m.cancelled = true
 			return m, tea.Quit
 
-		case "tab", "down":
+		// checked before next, enter moves to the next field
+		case key.Matches(msg, activeKeys.Form.Submit):
+			return m, m.submit()
+
+		case key.Matches(msg, activeKeys.Form.Next):
 			m.focusIndex = (m.focusIndex + 1) % len(m.inputs)
 			return m, nil
 
-		case "shift+tab", "up":
+		case key.Matches(msg, activeKeys.Form.Prev):
 			m.focusIndex--
 			if m.focusIndex < 0 {
 				m.focusIndex = len(m.inputs) - 1
 			}
 			return m, nil
 
-		case "enter":
-			if msg.Alt {
-				// Alt+Enter submits the form
-				return m, m.submit()
-			}
-			// Regular Enter moves to next field
-			m.focusIndex = (m.focusIndex + 1) % len(m.inputs)
-			return m, nil
-
-		case "ctrl+s":
-			return m, m.submit()
-
-		case "backspace":
+		case msg.Type == tea.KeyBackspace:
 			if len(m.inputs[m.focusIndex].value) > 0 {
 				m.inputs[m.focusIndex].value = m.inputs[m.focusIndex].value[:len(m.inputs[m.focusIndex].value)-1]
 			}
@@ -133,12 +126,7 @@ func (m *tunnelFormModel) View() string {
 		title = "Edit Tunnel"
 	}
 
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("212")).
-		Padding(1, 0)
-
-	b.WriteString(titleStyle.Render(title))
+	b.WriteString(theme.FormTitleStyle.Render(title))
 	b.WriteString("\n\n")
 
 	for i, input := range m.inputs {
@@ -151,22 +139,14 @@ func (m *tunnelFormModel) View() string {
 
 		isFocused := i == m.focusIndex
 
-		labelStyle := lipgloss.NewStyle().
-			Width(15).
-			Foreground(lipgloss.Color("240"))
-
+		labelStyle := theme.LabelStyle.Width(15)
 		if input.required {
-			labelStyle = labelStyle.Foreground(lipgloss.Color("205"))
+			labelStyle = theme.RequiredLabelStyle.Width(15)
 		}
 
-		inputStyle := lipgloss.NewStyle().
-			Width(40).
-			Foreground(lipgloss.Color("255"))
-
+		inputStyle := theme.InputStyle.Width(40)
 		if isFocused {
-			inputStyle = inputStyle.
-				Foreground(lipgloss.Color("212")).
-				Bold(true)
+			inputStyle = theme.FocusedInputStyle.Width(40)
 		}
 
 		label := labelStyle.Render(input.label + ":")
@@ -174,7 +154,7 @@ func (m *tunnelFormModel) View() string {
 		value := input.value
 		if value == "" {
 			value = input.placeholder
-			inputStyle = inputStyle.Foreground(lipgloss.Color("240")).Italic(true)
+			inputStyle = theme.PlaceholderStyle.Width(40)
 		}
 
 		if isFocused {
@@ -185,27 +165,13 @@ func (m *tunnelFormModel) View() string {
 	}
 
 	if m.err != "" {
-		errStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			Bold(true).
-			Padding(1, 0)
 		b.WriteString("\n")
-		b.WriteString(errStyle.Render("Error: " + m.err))
+		b.WriteString(theme.FormErrorStyle.Render("Error: " + m.err))
 	}
 
-	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240")).
-		Padding(1, 0)
-
-	help := "\ntab/↑/↓ navigate • ctrl+s save • esc cancel"
-	b.WriteString(helpStyle.Render(help))
-
-	boxStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("212")).
-		Padding(1, 2)
+	b.WriteString(theme.FormHelpStyle.Render("\n" + formHelp()))
 
-	return boxStyle.Render(b.String())
+	return theme.FormStyle.Render(b.String())
 }
 
 func (m *tunnelFormModel) submit() tea.Cmd {
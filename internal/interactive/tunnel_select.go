@@ -8,6 +8,7 @@ import (
 	"github.com/MrLonely14/ggh/internal/settings"
 	"github.com/MrLonely14/ggh/internal/theme"
 	"github.com/MrLonely14/ggh/internal/tunnel"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -30,6 +31,7 @@ type tunnelModel struct {
 	multiSelect   bool
 	showingForm   bool
 	formModel     *tunnelFormModel
+	showHelp      bool
 }
 
 func (m tunnelModel) Init() tea.Cmd { return nil }
@@ -65,6 +67,12 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.ExitAltScreen
 
 	case tea.KeyMsg:
+		if m.showHelp {
+			// any key closes the help
+			m.showHelp = false
+			return m, nil
+		}
+
 		if m.filtering {
 			switch msg.Type {
 			case tea.KeyRunes:
@@ -80,8 +88,8 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		switch msg.String() {
-		case "/":
+		switch {
+		case key.Matches(msg, activeKeys.List.Filter):
 			if !m.filtering {
 				m.filtering = true
 				m.filterText = ""
@@ -90,13 +98,13 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "n":
+		case key.Matches(msg, activeKeys.Tunnels.New):
 			// Create new tunnel
 			m.showingForm = true
 			m.formModel = newTunnelForm(nil)
 			return m, nil
 
-		case "e":
+		case key.Matches(msg, activeKeys.Tunnels.Edit):
 			// Edit selected tunnel
 			selectedRow := m.table.SelectedRow()
 			if selectedRow == nil {
@@ -112,7 +120,7 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "d":
+		case key.Matches(msg, activeKeys.Tunnels.Delete):
 			// Delete selected tunnel
 			selectedRow := m.table.SelectedRow()
 			if selectedRow == nil {
@@ -147,7 +155,7 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case " ":
+		case key.Matches(msg, activeKeys.Tunnels.Toggle):
 			// Toggle selection for multi-select
 			if m.multiSelect {
 				selectedRow := m.table.SelectedRow()
@@ -162,7 +170,7 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "w":
+		case key.Matches(msg, activeKeys.List.Fullscreen):
 			// Toggle fullscreen mode
 			newsettings := settings.Get()
 			newsettings.Fullscreen = !newsettings.Fullscreen
@@ -177,15 +185,19 @@ func (m tunnelModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case "q", "ctrl+c", "esc":
-			if m.filtering {
-				m.stopFiltering()
-				return m, nil
-			}
+		case m.filtering && key.Matches(msg, activeKeys.List.StopFilter):
+			m.stopFiltering()
+			return m, nil
+
+		case key.Matches(msg, activeKeys.List.Quit):
 			m.exit = true
 			return m, tea.Quit
 
-		case "enter":
+		case key.Matches(msg, activeKeys.List.Help):
+			m.showHelp = true
+			return m, nil
+
+		case key.Matches(msg, activeKeys.List.Choose):
 			if m.multiSelect && len(m.selectedIDs) > 0 {
 				// Return all selected tunnels
 				return m, tea.Quit
@@ -219,9 +231,7 @@ func (m tunnelModel) View() string {
 			m.windowHeight,
 		)
 
-		styled := lipgloss.NewStyle().
-			Foreground(lipgloss.AdaptiveColor{Light: "#FF0060", Dark: "#FF79C6"}).
-			Render(msg)
+		styled := theme.ErrorStyle.Render(msg)
 
 		pad := max((m.windowWidth-lipgloss.Width(styled))/2, 0)
 		centered := strings.Repeat(" ", pad) + styled
@@ -229,51 +239,45 @@ func (m tunnelModel) View() string {
 		return centered
 	}
 
+	if m.showHelp {
+		return fullHelp(m.keyHelp())
+	}
+
 	return theme.BaseStyle.Render(m.table.View()) + "\n" + m.helpView()
 }
 
-func (m tunnelModel) helpView() string {
-	var blocks []string
-
+// keyHelp returns the help of the active keys
+func (m tunnelModel) keyHelp() helpKeyMap {
+	k := activeKeys
 	if m.filtering {
-		blocks = append(blocks, "esc quit filter")
-	} else {
-		km := table.DefaultKeyMap()
-		blocks = append(blocks,
-			fmt.Sprintf("%s %s", km.LineUp.Help().Key, km.LineUp.Help().Desc),
-			fmt.Sprintf("%s %s", km.LineDown.Help().Key, km.LineDown.Help().Desc),
-			"n new",
-			"e edit",
-			"d delete",
-		)
+		return helpKeyMap{short: []key.Binding{k.List.StopFilter}}
+	}
 
-		if m.multiSelect {
-			blocks = append(blocks, "space select")
-		}
+	actions := []key.Binding{k.Tunnels.New, k.Tunnels.Edit, k.Tunnels.Delete}
+	if m.multiSelect {
+		actions = append(actions, k.Tunnels.Toggle)
+	}
+	general := []key.Binding{k.List.Choose, k.List.Fullscreen, k.List.Filter, k.List.Quit}
 
-		blocks = append(blocks,
-			"w window/full",
-			"/ filter",
-			"q quit",
-		)
+	short := append([]key.Binding{k.List.Up, k.List.Down}, actions...)
+	short = append(short, k.List.Fullscreen, k.List.Filter, k.List.Quit, k.List.Help)
+	return helpKeyMap{
+		short: short,
+		full:  [][]key.Binding{k.List.navigation(), actions, general},
 	}
+}
 
-	help := lipgloss.NewStyle().
-		Foreground(lipgloss.AdaptiveColor{Light: "#B2B2B2", Dark: "#4A4A4A"}).
-		Render(strings.Join(blocks, " • "))
+func (m tunnelModel) helpView() string {
+	help := helpBar(m.keyHelp().short...)
 
 	if m.filtering {
-		prompt := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("57")).
-			Render("/" + m.filterText)
-		return " " + help + prompt
+		prompt := theme.PromptStyle.Render("/" + m.filterText)
+		return " " + help + theme.HelpStyle.Render(" • ") + prompt
 	}
 
 	selectedCount := len(m.selectedIDs)
 	if selectedCount > 0 {
-		countMsg := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("212")).
-			Render(fmt.Sprintf(" [%d selected]", selectedCount))
+		countMsg := theme.CountStyle.Render(fmt.Sprintf(" [%d selected]", selectedCount))
 		return " " + help + countMsg
 	}
 
@@ -322,7 +326,7 @@ func SelectTunnels(multiSelect bool) []tunnel.Tunnel {
 	}
 
 	if len(tunnels) == 0 {
-		fmt.Println("No tunnels configured. Use 'n' to create a new tunnel.")
+		fmt.Printf("No tunnels configured. Use '%s' to create a new tunnel.\n", activeKeys.Tunnels.New.Help().Key)
 	}
 
 	rows := tunnelsToRows(tunnels)
@@ -331,6 +335,7 @@ func SelectTunnels(multiSelect bool) []tunnel.Tunnel {
 		table.WithColumns(theme.GetColumns(theme.TunnelTable)),
 		table.WithRows(rows),
 		table.WithFocused(true),
+		table.WithKeyMap(activeKeys.List.table()),
 	)
 
 	s := table.DefaultStyles()
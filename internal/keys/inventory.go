@@ -0,0 +1,129 @@
+package keys
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/output"
+)
+
+// Expand resolves ~ in a key path and makes relative paths relative to
+// ~/.ssh, as ssh does for IdentityFile. Keys given on the command line go
+// through ArgPath first.
+func Expand(path string) string {
+	switch {
+	case path == "~":
+		return config.HomeDir()
+	case strings.HasPrefix(path, "~/"):
+		return filepath.Join(config.HomeDir(), path[2:])
+	case path != "" && !filepath.IsAbs(path):
+		return filepath.Join(config.GetSshDir(), path)
+	}
+	return path
+}
+
+// ArgPath makes a relative key path given with -i absolute, ssh reads it
+// from the working directory. Paths starting with ~ are left to Expand.
+func ArgPath(path string) string {
+	if path == "" || strings.HasPrefix(path, "~") || filepath.IsAbs(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// Scan returns the private keys in dir
+func Scan(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err == nil && IsPrivate(content) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// Inventory inspects the keys in ~/.ssh and the ones hosts use, with the
+// hosts using each of them
+func Inventory(hosts []config.SSHConfig) ([]Key, error) {
+	paths, err := Scan(config.GetSshDir())
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string][]string{}
+	for _, c := range hosts {
+		if c.Key == "" {
+			continue
+		}
+		path := Expand(c.Key)
+		if _, ok := users[path]; !ok && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+		users[path] = append(users[path], c.DisplayName())
+	}
+	sort.Strings(paths)
+
+	list := make([]Key, 0, len(paths))
+	for _, path := range paths {
+		k := Inspect(path)
+		k.Hosts = users[path]
+		list = append(list, k)
+	}
+	return list, nil
+}
+
+// Check returns the warnings of the key at path, none for an empty path
+func Check(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return Inspect(path).Warnings()
+}
+
+// RecordKeys are the fields of a key in machine readable output
+var RecordKeys = []string{"path", "type", "bits", "fingerprint", "comment", "passphrase", "mode", "missing", "hosts"}
+
+// Records converts keys to output records. Mode is the permission bits in
+// octal, hosts the aliases and connections using the key.
+func Records(list []Key) []output.Record {
+	records := make([]output.Record, 0, len(list))
+	for _, k := range list {
+		mode := ""
+		if !k.Missing {
+			mode = fmt.Sprintf("%04o", k.Mode.Perm())
+		}
+		records = append(records, output.Record{
+			{Key: "path", Value: k.Path},
+			{Key: "type", Value: k.Type},
+			{Key: "bits", Value: k.Bits},
+			{Key: "fingerprint", Value: k.Fingerprint},
+			{Key: "comment", Value: k.Comment},
+			{Key: "passphrase", Value: k.Passphrase},
+			{Key: "mode", Value: mode},
+			{Key: "missing", Value: k.Missing},
+			{Key: "hosts", Value: strings.Join(k.Hosts, ",")},
+		})
+	}
+	return records
+}
@@ -0,0 +1,227 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Passphrase states of a private key
+const (
+	PassphraseSet     = "yes"
+	PassphraseNone    = "no"
+	PassphraseUnknown = "unknown"
+)
+
+const opensshMagic = "openssh-key-v1\x00"
+
+// Key is a private key on disk and what its headers and public key tell
+// about it. Nothing is decrypted.
+type Key struct {
+	// Path is the private key, as referenced, with ~ expanded
+	Path string
+	// Missing is set when Path doesn't exist
+	Missing bool
+	Type    string
+	// Bits is 0 when the public key isn't available
+	Bits        int
+	Fingerprint string
+	Comment     string
+	// Passphrase is PassphraseSet, PassphraseNone or PassphraseUnknown
+	Passphrase string
+	Mode       os.FileMode
+	// Hosts are the aliases and connections using the key
+	Hosts []string
+}
+
+// Warnings returns what would make ssh fail to use the key
+func (k Key) Warnings() []string {
+	if k.Missing {
+		return []string{"key file " + k.Path + " doesn't exist"}
+	}
+	// ssh refuses private keys others can read, Windows has no such modes
+	if runtime.GOOS != "windows" && k.Mode.Perm()&0077 != 0 {
+		return []string{"key file " + k.Path + " is readable by other users (" + k.Mode.Perm().String() + "), chmod 600 it"}
+	}
+	return nil
+}
+
+// Inspect reads the key at path, and its .pub file when there is one
+func Inspect(path string) Key {
+	k := Key{Path: Expand(path), Passphrase: PassphraseUnknown}
+
+	info, err := os.Stat(k.Path)
+	if err != nil {
+		k.Missing = os.IsNotExist(err)
+		return k
+	}
+	k.Mode = info.Mode()
+
+	content, err := os.ReadFile(k.Path)
+	if err != nil {
+		return k
+	}
+	blob := k.readPrivate(content)
+
+	// the .pub file has the comment, and the public key of PEM keys
+	if pub, err := os.ReadFile(k.Path + ".pub"); err == nil {
+		if keyType, pubBlob, comment, err := ParsePublic(pub); err == nil {
+			k.Type, blob, k.Comment = keyType, pubBlob, comment
+		}
+	}
+	if blob != nil {
+		k.Fingerprint = Fingerprint(blob)
+		k.Bits = bits(blob)
+	}
+	return k
+}
+
+// readPrivate fills in what the headers of a private key tell, and returns
+// its public key blob when the file holds it
+func (k *Key) readPrivate(content []byte) []byte {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil
+	}
+
+	switch block.Type {
+	case "OPENSSH PRIVATE KEY":
+		cipher, blob, err := parseOpenSSH(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		k.Passphrase = PassphraseNone
+		if cipher != "none" {
+			k.Passphrase = PassphraseSet
+		}
+		k.Type = blobType(blob)
+		return blob
+	case "ENCRYPTED PRIVATE KEY":
+		k.Passphrase = PassphraseSet
+	case "RSA PRIVATE KEY", "EC PRIVATE KEY", "DSA PRIVATE KEY", "PRIVATE KEY":
+		k.Passphrase = PassphraseNone
+		if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+			k.Passphrase = PassphraseSet
+		}
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k.Type = "ssh-rsa"
+	case "EC PRIVATE KEY":
+		k.Type = "ecdsa"
+	case "DSA PRIVATE KEY":
+		k.Type = "ssh-dss"
+	}
+	return nil
+}
+
+// IsPrivate reports whether content looks like a private key
+func IsPrivate(content []byte) bool {
+	block, _ := pem.Decode(content)
+	return block != nil && strings.HasSuffix(block.Type, "PRIVATE KEY")
+}
+
+// parseOpenSSH reads the cipher and the first public key of an
+// openssh-key-v1 private key, which are stored unencrypted
+func parseOpenSSH(data []byte) (string, []byte, error) {
+	if !bytes.HasPrefix(data, []byte(opensshMagic)) {
+		return "", nil, errors.New("not an openssh key")
+	}
+	r := reader(data[len(opensshMagic):])
+	cipher := r.string()
+	r.string() // kdf name
+	r.string() // kdf options
+	if r.uint32() < 1 {
+		return "", nil, errors.New("no key in the file")
+	}
+	blob := r.string()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	return string(cipher), blob, nil
+}
+
+// ParsePublic parses a public key line: type, base64 blob and comment
+func ParsePublic(line []byte) (string, []byte, string, error) {
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 {
+		return "", nil, "", errors.New("not a public key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", nil, "", err
+	}
+	return fields[0], blob, strings.Join(fields[2:], " "), nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of a public key blob, as
+// ssh-keygen -l prints it
+func Fingerprint(blob []byte) string {
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+func blobType(blob []byte) string {
+	r := reader(blob)
+	return string(r.string())
+}
+
+// bits returns the size of the public key in blob, 0 when unknown
+func bits(blob []byte) int {
+	r := reader(blob)
+	switch keyType := string(r.string()); keyType {
+	case "ssh-rsa":
+		r.string() // e
+		return new(big.Int).SetBytes(r.string()).BitLen()
+	case "ssh-dss":
+		return new(big.Int).SetBytes(r.string()).BitLen()
+	case "ssh-ed25519", "sk-ssh-ed25519@openssh.com":
+		return 256
+	case "ecdsa-sha2-nistp256", "sk-ecdsa-sha2-nistp256@openssh.com":
+		return 256
+	case "ecdsa-sha2-nistp384":
+		return 384
+	case "ecdsa-sha2-nistp521":
+		return 521
+	}
+	return 0
+}
+
+// wireReader reads the strings and integers of the ssh wire format
+type wireReader struct {
+	data []byte
+	err  error
+}
+
+func reader(data []byte) *wireReader {
+	return &wireReader{data: data}
+}
+
+func (r *wireReader) uint32() uint32 {
+	if r.err != nil || len(r.data) < 4 {
+		r.err = errors.New("truncated key")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *wireReader) string() []byte {
+	n := r.uint32()
+	if r.err != nil || uint32(len(r.data)) < n {
+		r.err = errors.New("truncated key")
+		return nil
+	}
+	s := r.data[:n]
+	r.data = r.data[n:]
+	return s
+}
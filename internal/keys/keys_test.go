@@ -0,0 +1,165 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+func wireString(b []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(b)))
+	return append(out, b...)
+}
+
+func mpint(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return wireString(b)
+}
+
+// opensshKey returns an openssh-key-v1 file holding an ed25519 public key,
+// the private part is left out as it is never read
+func opensshKey(t *testing.T, cipher string) ([]byte, []byte) {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := append(wireString([]byte("ssh-ed25519")), wireString(pub)...)
+
+	data := []byte(opensshMagic)
+	data = append(data, wireString([]byte(cipher))...)
+	data = append(data, wireString([]byte("none"))...)
+	data = append(data, wireString(nil)...)
+	data = binary.BigEndian.AppendUint32(data, 1)
+	data = append(data, wireString(blob)...)
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: data}), blob
+}
+
+func writeFile(t *testing.T, path string, content []byte, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, content, mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		expand string
+		arg    string
+	}{
+		{"~/.ssh/id_work", filepath.Join(home, ".ssh", "id_work"), "~/.ssh/id_work"},
+		{"id_work", filepath.Join(home, ".ssh", "id_work"), filepath.Join(wd, "id_work")},
+		{"./keys/id_work", filepath.Join(home, ".ssh", "keys", "id_work"), filepath.Join(wd, "keys", "id_work")},
+		{"/keys/id_work", "/keys/id_work", "/keys/id_work"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Expand(tt.path); got != tt.expand {
+			t.Errorf("Expand(%q) = %q, want %q", tt.path, got, tt.expand)
+		}
+		if got := ArgPath(tt.path); got != tt.arg {
+			t.Errorf("ArgPath(%q) = %q, want %q", tt.path, got, tt.arg)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	ssh := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(ssh, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, plainBlob := opensshKey(t, "none")
+	writeFile(t, filepath.Join(ssh, "id_ed25519"), plain, 0600)
+	writeFile(t, filepath.Join(ssh, "id_ed25519.pub"), []byte("ssh-ed25519 "+base64.StdEncoding.EncodeToString(plainBlob)+" me@laptop\n"), 0644)
+
+	encrypted, _ := opensshKey(t, "aes256-ctr")
+	writeFile(t, filepath.Join(ssh, "deploy"), encrypted, 0644)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(ssh, "legacy"), pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), 0600)
+	rsaBlob := append(wireString([]byte("ssh-rsa")), mpint(big.NewInt(int64(rsaKey.E)))...)
+	rsaBlob = append(rsaBlob, mpint(rsaKey.N)...)
+	writeFile(t, filepath.Join(ssh, "legacy.pub"), []byte("ssh-rsa "+base64.StdEncoding.EncodeToString(rsaBlob)), 0644)
+
+	writeFile(t, filepath.Join(ssh, "old"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-128-CBC,00"}, Bytes: []byte{1}}), 0600)
+	writeFile(t, filepath.Join(ssh, "known_hosts"), []byte("host ssh-ed25519 AAAA\n"), 0644)
+
+	tests := []struct {
+		path       string
+		keyType    string
+		bits       int
+		passphrase string
+		comment    string
+		warnings   int
+	}{
+		{"~/.ssh/id_ed25519", "ssh-ed25519", 256, PassphraseNone, "me@laptop", 0},
+		{"deploy", "ssh-ed25519", 256, PassphraseSet, "", 1},
+		{filepath.Join(ssh, "legacy"), "ssh-rsa", 2048, PassphraseNone, "", 0},
+		{"~/.ssh/old", "ecdsa", 0, PassphraseSet, "", 0},
+		{"~/.ssh/nope", "", 0, PassphraseUnknown, "", 1},
+	}
+	for _, tt := range tests {
+		k := Inspect(tt.path)
+		if k.Type != tt.keyType || k.Bits != tt.bits || k.Passphrase != tt.passphrase || k.Comment != tt.comment || len(k.Warnings()) != tt.warnings {
+			t.Errorf("Inspect(%q) = %+v, warnings %q", tt.path, k, k.Warnings())
+		}
+	}
+
+	if k := Inspect("~/.ssh/id_ed25519"); k.Fingerprint != Fingerprint(plainBlob) || !strings.HasPrefix(k.Fingerprint, "SHA256:") {
+		t.Errorf("Fingerprint = %q", k.Fingerprint)
+	}
+
+	list, err := Inventory([]config.SSHConfig{
+		{Name: "web", Host: "web.example.com", Key: "~/.ssh/deploy"},
+		{Name: config.DirectSSH, Host: "10.0.0.5", User: "root", Key: "~/.ssh/deploy"},
+		{Name: "gone", Host: "gone.example.com", Key: "~/keys/gone"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := map[string]Key{}
+	for _, k := range list {
+		byPath[k.Path] = k
+	}
+	if len(list) != 5 {
+		t.Errorf("Inventory() = %d keys, want the 4 in ~/.ssh and the missing one", len(list))
+	}
+	if got := strings.Join(byPath[filepath.Join(ssh, "deploy")].Hosts, ","); got != "web,root@10.0.0.5" {
+		t.Errorf("hosts of deploy = %q", got)
+	}
+	if k := byPath[filepath.Join(home, "keys", "gone")]; !k.Missing || strings.Join(k.Hosts, ",") != "gone" {
+		t.Errorf("missing key = %+v", k)
+	}
+}
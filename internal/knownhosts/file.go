@@ -0,0 +1,76 @@
+package knownhosts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+// Path returns ~/.ssh/known_hosts
+func Path() string {
+	return filepath.Join(config.GetSshDir(), "known_hosts")
+}
+
+// Load reads the entries of the known_hosts file at path, a missing file
+// has none
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Remove deletes the lines of entries from the known_hosts file at path,
+// after copying it to ~/.ggh/backups. It returns the backup's location.
+func Remove(path string, entries []Entry) (string, error) {
+	if len(entries) == 0 {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	// the file may have changed since the entries were read, drop nothing
+	// unless every line still holds its entry
+	lines := strings.SplitAfter(string(content), "\n")
+	drop := make(map[int]bool, len(entries))
+	for _, e := range entries {
+		var current Entry
+		ok := e.Line >= 1 && e.Line <= len(lines)
+		if ok {
+			current, ok = parseLine(lines[e.Line-1], e.Line)
+		}
+		if !ok || !current.sameKey(e) {
+			return "", fmt.Errorf("line %d of %s changed since it was read, nothing removed", e.Line, path)
+		}
+		drop[e.Line] = true
+	}
+
+	backup, err := config.Backup(path)
+	if err != nil {
+		return "", err
+	}
+
+	var kept strings.Builder
+	for i, line := range lines {
+		if !drop[i+1] {
+			kept.WriteString(line)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return backup, err
+	}
+	return backup, os.WriteFile(path, []byte(kept.String()), info.Mode().Perm())
+}
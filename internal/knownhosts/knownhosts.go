@@ -0,0 +1,196 @@
+package knownhosts
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	// CertAuthority marks a key trusted to sign host certificates
+	CertAuthority = "@cert-authority"
+	// Revoked marks a key that must never be accepted
+	Revoked = "@revoked"
+
+	hashPrefix = "|1|"
+)
+
+// Entry is a host key line of a known_hosts file
+type Entry struct {
+	// Line is the line number in the file, starting at 1
+	Line int
+	// Marker is CertAuthority, Revoked or empty
+	Marker string
+	// Patterns are the host patterns as written, hashed ones included
+	Patterns []string
+	KeyType  string
+	// Key is the base64 encoded public key
+	Key     string
+	Comment string
+}
+
+// Parse reads the entries of a known_hosts file. Comments, blank and
+// malformed lines are skipped.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		if e, ok := parseLine(scanner.Text(), n); ok {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// parseLine parses the line number n, it returns false for comments, blank
+// and malformed lines
+func parseLine(line string, n int) (Entry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Entry{}, false
+	}
+
+	fields := strings.Fields(line)
+	e := Entry{Line: n}
+	if strings.HasPrefix(fields[0], "@") {
+		e.Marker, fields = fields[0], fields[1:]
+	}
+	if len(fields) < 3 {
+		return Entry{}, false
+	}
+	e.Patterns = strings.Split(fields[0], ",")
+	e.KeyType = fields[1]
+	e.Key = fields[2]
+	e.Comment = strings.Join(fields[3:], " ")
+	return e, true
+}
+
+// sameKey reports whether e and other are the same host key line, the comment
+// aside
+func (e Entry) sameKey(other Entry) bool {
+	return e.Marker == other.Marker && e.KeyType == other.KeyType && e.Key == other.Key &&
+		slices.Equal(e.Patterns, other.Patterns)
+}
+
+// Hashed reports whether the host names of the entry are hashed
+func (e Entry) Hashed() bool {
+	for _, p := range e.Patterns {
+		if strings.HasPrefix(p, hashPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hosts returns the readable patterns of the entry, without the hashed ones
+// and the negations
+func (e Entry) Hosts() []string {
+	var hosts []string
+	for _, p := range e.Patterns {
+		if !strings.HasPrefix(p, hashPrefix) && !strings.HasPrefix(p, "!") {
+			hosts = append(hosts, p)
+		}
+	}
+	return hosts
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key, as ssh-keygen -l
+// prints it
+func (e Entry) Fingerprint() string {
+	blob, err := base64.StdEncoding.DecodeString(e.Key)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// HostName returns the name ssh looks a host up with: the host, or
+// [host]:port when the port isn't 22
+func HostName(host, port string) string {
+	if port == "" || port == "22" {
+		return host
+	}
+	return "[" + host + "]:" + port
+}
+
+// Matches reports whether the entry is for host on port, the way ssh
+// matches it: a negated pattern that matches excludes the host
+func (e Entry) Matches(host, port string) bool {
+	name := strings.ToLower(HostName(host, port))
+	matched := false
+	for _, p := range e.Patterns {
+		negated := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+		if !matchPattern(p, name) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// matchPattern matches a plain, wildcard or hashed pattern against name
+func matchPattern(pattern, name string) bool {
+	if strings.HasPrefix(pattern, hashPrefix) {
+		salt, hash, ok := strings.Cut(pattern[len(hashPrefix):], "|")
+		if !ok {
+			return false
+		}
+		return hashMatches(salt, hash, name)
+	}
+	return wildcard(strings.ToLower(pattern), name)
+}
+
+// wildcard matches name against a pattern where * is any run of characters
+// and ? a single one. Brackets are literal, as in [host]:port.
+func wildcard(pattern, name string) bool {
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			for i := len(name); i >= 0; i-- {
+				if wildcard(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if name == "" {
+				return false
+			}
+		default:
+			if name == "" || name[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return name == ""
+}
+
+// hashMatches checks name against a |1|salt|hash pattern, the HMAC-SHA1 of
+// the name keyed with the salt
+func hashMatches(salt, hash, name string) bool {
+	key, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return false
+	}
+	want, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, key)
+	mac.Write([]byte(name))
+	return hmac.Equal(mac.Sum(nil), want)
+}
@@ -0,0 +1,192 @@
+package knownhosts
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+// hashed returns name hashed the way HashKnownHosts writes it
+func hashed(name string) string {
+	salt := []byte("0123456789abcdefghij")
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(name))
+	return "|1|" + base64.StdEncoding.EncodeToString(salt) + "|" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+const key = "AAAAC3NzaC1lZDI1NTE5AAAAIGm3n2Uo7yUFFb7rKj0Jm7zNdu7c4Gr1yBbB6fVTl3Ki"
+
+func knownHostsFile() string {
+	return "# servers\n" +
+		"web.example.com,10.0.0.1 ssh-ed25519 " + key + " root@web\n" +
+		"\n" +
+		"[10.0.0.2]:2222 ssh-ed25519 " + key + "\n" +
+		hashed("db.internal") + " ecdsa-sha2-nistp256 " + key + "\n" +
+		"@cert-authority *.example.com,!old.example.com ssh-ed25519 " + key + "\n" +
+		"@revoked 10.9.9.9 ssh-rsa " + key + "\n" +
+		"gone.example.com ssh-ed25519 " + key + "\n" +
+		"malformed-line\n"
+}
+
+func TestParse(t *testing.T) {
+	entries, err := Parse(strings.NewReader(knownHostsFile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Parse() found %d entries, want 6: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if first.Line != 2 || first.KeyType != "ssh-ed25519" || first.Comment != "root@web" ||
+		strings.Join(first.Hosts(), ",") != "web.example.com,10.0.0.1" || first.Hashed() {
+		t.Errorf("first entry = %+v", first)
+	}
+	if !entries[2].Hashed() || len(entries[2].Hosts()) != 0 {
+		t.Errorf("hashed entry = %+v", entries[2])
+	}
+	if entries[3].Marker != CertAuthority || entries[4].Marker != Revoked {
+		t.Errorf("markers = %q, %q", entries[3].Marker, entries[4].Marker)
+	}
+	if got := first.Fingerprint(); !strings.HasPrefix(got, "SHA256:") || len(got) != 50 {
+		t.Errorf("Fingerprint() = %q", got)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	entries, err := Parse(strings.NewReader(knownHostsFile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entry      int
+		host, port string
+		want       bool
+	}{
+		{0, "web.example.com", "", true},
+		{0, "WEB.example.com", "22", true},
+		{0, "10.0.0.1", "22", true},
+		{0, "web.example.com", "2222", false},
+		{1, "10.0.0.2", "2222", true},
+		{1, "10.0.0.2", "22", false},
+		{2, "db.internal", "22", true},
+		{2, "db.external", "22", false},
+		{3, "api.example.com", "", true},
+		{3, "old.example.com", "", false},
+	}
+	for _, tt := range tests {
+		if got := entries[tt.entry].Matches(tt.host, tt.port); got != tt.want {
+			t.Errorf("entry %d Matches(%q, %q) = %v, want %v", tt.entry, tt.host, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestCrossReference(t *testing.T) {
+	entries, err := Parse(strings.NewReader(knownHostsFile()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := []config.SSHConfig{
+		{Name: "web", Host: "web.example.com"},
+		{Name: "db", Host: "db.internal", Port: "22"},
+		{Name: config.DirectSSH, Host: "10.0.0.2", Port: "2222", User: "deploy"},
+	}
+
+	usages := CrossReference(entries, hosts)
+	want := []string{"web", "deploy@10.0.0.2", "db", "web", "revoked", StaleMark}
+	for i, u := range usages {
+		if got := u.UsedByColumn(); got != want[i] {
+			t.Errorf("entry %d used by %q, want %q", i, got, want[i])
+		}
+	}
+	if got := usages[2].Name(); got != "hashed: db" {
+		t.Errorf("hashed entry Name() = %q", got)
+	}
+	if got := For(entries, hosts[0]); len(got) != 2 {
+		t.Errorf("For(web) = %+v, want the plain and the CA entries", got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, "known_hosts")
+	if err := os.WriteFile(path, []byte(knownHostsFile()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := Remove(path, []Entry{entries[1], entries[5]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := os.ReadFile(backup)
+	if err != nil || string(saved) != knownHostsFile() {
+		t.Errorf("backup %s doesn't hold the previous file: %v", backup, err)
+	}
+	left, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 4 {
+		t.Fatalf("%d entries left, want 4", len(left))
+	}
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), "10.0.0.2") || strings.Contains(string(content), "gone.example.com") ||
+		!strings.Contains(string(content), "# servers\n") || !strings.Contains(string(content), "malformed-line\n") {
+		t.Errorf("known_hosts after Remove:\n%s", content)
+	}
+
+	if entries, err := Load(filepath.Join(home, "missing")); err != nil || entries != nil {
+		t.Errorf("Load(missing) = %v, %v", entries, err)
+	}
+}
+
+func TestRemoveChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(content string) string
+	}{
+		{"line inserted above", func(content string) string { return "# added\n" + content }},
+		{"key replaced", func(content string) string {
+			return strings.Replace(content, "[10.0.0.2]:2222 ssh-ed25519", "[10.0.0.2]:2222 ssh-rsa", 1)
+		}},
+		{"file truncated", func(content string) string { return "# servers\n" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			path := filepath.Join(t.TempDir(), "known_hosts")
+			if err := os.WriteFile(path, []byte(knownHostsFile()), 0600); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changed := tt.change(knownHostsFile())
+			if err := os.WriteFile(path, []byte(changed), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Remove(path, []Entry{entries[1]}); err == nil {
+				t.Error("Remove() succeeded on a changed file")
+			}
+			if content, _ := os.ReadFile(path); string(content) != changed {
+				t.Errorf("Remove() modified the file:\n%s", content)
+			}
+		})
+	}
+}
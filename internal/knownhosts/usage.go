@@ -0,0 +1,99 @@
+package knownhosts
+
+import (
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/output"
+)
+
+// StaleMark is shown instead of the hosts using a stale entry
+const StaleMark = config.MissingConfig + "stale"
+
+// Usage is an entry with the hosts of the ssh config and history it is for
+type Usage struct {
+	Entry
+	// UsedBy are the aliases, or user@host for direct connections
+	UsedBy []string
+}
+
+// Stale reports whether the entry is for none of the hosts in use. Revoked
+// keys are never stale, they stay refused.
+func (u Usage) Stale() bool {
+	return len(u.UsedBy) == 0 && u.Marker != Revoked
+}
+
+// Name returns the hosts of the entry for display: its readable patterns,
+// or the hosts in use it was matched with when it is hashed
+func (u Usage) Name() string {
+	names := u.Hosts()
+	if u.Hashed() {
+		if len(u.UsedBy) > 0 {
+			names = append(names, "hashed: "+strings.Join(u.UsedBy, ", "))
+		} else {
+			names = append(names, "hashed")
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// UsedByColumn returns the hosts using the entry for display, or marks it
+// revoked or stale
+func (u Usage) UsedByColumn() string {
+	switch {
+	case u.Marker == Revoked:
+		return "revoked"
+	case u.Stale():
+		return StaleMark
+	}
+	return strings.Join(u.UsedBy, ", ")
+}
+
+// CrossReference matches every entry with the hosts it is for
+func CrossReference(entries []Entry, hosts []config.SSHConfig) []Usage {
+	usages := make([]Usage, 0, len(entries))
+	for _, e := range entries {
+		u := Usage{Entry: e}
+		for _, c := range hosts {
+			if e.Matches(c.Host, c.Port) {
+				u.UsedBy = append(u.UsedBy, c.DisplayName())
+			}
+		}
+		usages = append(usages, u)
+	}
+	return usages
+}
+
+// For returns the entries ssh checks when connecting to c, the ones
+// ssh-keygen -R would remove
+func For(entries []Entry, c config.SSHConfig) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if e.Matches(c.Host, c.Port) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// RecordKeys are the fields of a known_hosts entry in machine readable output
+var RecordKeys = []string{"line", "marker", "hosts", "hashed", "type", "fingerprint", "used_by", "stale"}
+
+// Records converts entries to output records. Hosts are the readable
+// patterns, used_by the aliases and connections the entry is for.
+func Records(usages []Usage) []output.Record {
+	records := make([]output.Record, 0, len(usages))
+	for _, u := range usages {
+		records = append(records, output.Record{
+			{Key: "line", Value: u.Line},
+			{Key: "marker", Value: u.Marker},
+			{Key: "hosts", Value: strings.Join(u.Hosts(), ",")},
+			{Key: "hashed", Value: u.Hashed()},
+			{Key: "type", Value: u.KeyType},
+			{Key: "fingerprint", Value: u.Fingerprint()},
+			{Key: "used_by", Value: strings.Join(u.UsedBy, ",")},
+			{Key: "stale", Value: u.Stale()},
+		})
+	}
+	return records
+}
@@ -0,0 +1,115 @@
+package meta
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FavoriteMark starts the tags column of favorite hosts
+const FavoriteMark = "★"
+
+// Meta is what the user notes about a host, kept by ggh rather than in the
+// ssh config
+type Meta struct {
+	// Tags are free-form, like env:prod or team:payments
+	Tags     []string `json:"tags,omitempty"`
+	Note     string   `json:"note,omitempty"`
+	Favorite bool     `json:"favorite,omitempty"`
+}
+
+// IsZero reports whether nothing is noted
+func (m Meta) IsZero() bool {
+	return len(m.Tags) == 0 && m.Note == "" && !m.Favorite
+}
+
+// TagsColumn returns the tags for display, after FavoriteMark for favorites
+func (m Meta) TagsColumn() string {
+	tags := strings.Join(m.Tags, ", ")
+	if m.Favorite {
+		return strings.TrimSpace(FavoriteMark + " " + tags)
+	}
+	return tags
+}
+
+// ParseTags splits tags written separated by commas or spaces, dropping
+// duplicates
+func ParseTags(text string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Store is the metadata of every host, keyed by the UniqueKey of the host
+// with a clean name
+type Store map[string]Meta
+
+// Load reads ~/.ggh/meta.json, a missing file holds nothing
+func Load() (Store, error) {
+	store := Store{}
+	content, err := os.ReadFile(getFileLocation())
+	if os.IsNotExist(err) || len(content) == 0 {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+	if err := json.Unmarshal(content, &store); err != nil {
+		return Store{}, err
+	}
+	return store, nil
+}
+
+// Save writes the store to ~/.ggh/meta.json
+func (s Store) Save() error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getFileLocation(), b, 0644)
+}
+
+// Get returns the metadata of the host with key
+func (s Store) Get(key string) Meta {
+	return s[key]
+}
+
+// Set replaces the metadata of the host with key, removing it when empty
+func (s Store) Set(key string, m Meta) {
+	if m.IsZero() {
+		delete(s, key)
+		return
+	}
+	s[key] = m
+}
+
+// Move gives the metadata of the host with key from to the host with key
+// to, when a host is renamed
+func (s Store) Move(from, to string) {
+	if m, ok := s[from]; ok {
+		delete(s, from)
+		s[to] = m
+	}
+}
+
+func getFileLocation() string {
+	userHomeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return ""
+	}
+
+	gghConfigDir := filepath.Join(userHomeDir, ".ggh")
+
+	if err := os.MkdirAll(gghConfigDir, 0700); err != nil {
+		return ""
+	}
+
+	return filepath.Join(gghConfigDir, "meta.json")
+}
@@ -0,0 +1,82 @@
+package meta
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"env:prod", []string{"env:prod"}},
+		{"env:prod, team:payments", []string{"env:prod", "team:payments"}},
+		{"env:prod team:payments,,env:prod", []string{"env:prod", "team:payments"}},
+	}
+	for _, tt := range tests {
+		if got := ParseTags(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTagsColumn(t *testing.T) {
+	tests := []struct {
+		m    Meta
+		want string
+	}{
+		{Meta{}, ""},
+		{Meta{Tags: []string{"env:prod", "team:web"}}, "env:prod, team:web"},
+		{Meta{Favorite: true}, FavoriteMark},
+		{Meta{Tags: []string{"env:prod"}, Favorite: true}, FavoriteMark + " env:prod"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.TagsColumn(); got != tt.want {
+			t.Errorf("TagsColumn() of %+v = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := Load()
+	if err != nil {
+		t.Fatalf("Load() without a file error = %v", err)
+	}
+	if len(store) != 0 {
+		t.Fatalf("Load() without a file = %v, want empty", store)
+	}
+
+	store.Set("web", Meta{Tags: []string{"env:prod"}, Note: "behind the lb"})
+	store.Set("10.0.0.522root", Meta{Favorite: true})
+	store.Set("gone", Meta{})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, store) {
+		t.Errorf("Load() = %v, want %v", loaded, store)
+	}
+	if _, ok := loaded["gone"]; ok {
+		t.Errorf("an empty Meta should not be stored")
+	}
+
+	// promoting a direct connection moves its metadata to the alias
+	loaded.Move("10.0.0.522root", "db")
+	if !loaded.Get("db").Favorite || !loaded.Get("10.0.0.522root").IsZero() {
+		t.Errorf("Move() = %v", loaded)
+	}
+
+	// clearing everything removes the host
+	loaded.Set("web", Meta{})
+	if _, ok := loaded["web"]; ok {
+		t.Errorf("Set() with an empty Meta should remove the host")
+	}
+}
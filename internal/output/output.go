@@ -0,0 +1,189 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Format is a way of printing a listing
+type Format string
+
+const (
+	// Table is the bordered, colored table meant for humans
+	Table Format = "table"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	CSV   Format = "csv"
+	TSV   Format = "tsv"
+	// Plain is aligned columns without borders or colors
+	Plain Format = "plain"
+)
+
+// Formats lists the accepted values of --output
+var Formats = []Format{Table, JSON, YAML, CSV, TSV, Plain}
+
+// ParseFormat returns the format called name. An empty name picks the
+// table when stdout is a terminal and plain output otherwise.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		if isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+			return Table, nil
+		}
+		return Plain, nil
+	}
+
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return "", fmt.Errorf("unknown output format %q (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// Field is a named value of a record
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Record is a listing row whose fields keep their order in every format
+type Record []Field
+
+// MarshalJSON encodes the record as an object with the fields in order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.Key)
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Write prints the records in one of the machine readable formats. The
+// keys are taken from keys, so an empty listing still has a CSV header.
+func Write(w io.Writer, format Format, keys []string, records []Record) error {
+	switch format {
+	case JSON:
+		if records == nil {
+			records = []Record{}
+		}
+		b, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+
+	case YAML:
+		if len(records) == 0 {
+			_, err := fmt.Fprintln(w, "[]")
+			return err
+		}
+		for _, r := range records {
+			for i, f := range r {
+				prefix := "  "
+				if i == 0 {
+					prefix = "- "
+				}
+				if _, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, f.Key, yamlValue(f.Value)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case CSV, TSV:
+		cw := csv.NewWriter(w)
+		if format == TSV {
+			cw.Comma = '\t'
+		}
+		_ = cw.Write(keys)
+		for _, r := range records {
+			_ = cw.Write(r.strings())
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case Plain:
+		var b bytes.Buffer
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(keys, "\t")))
+		for _, r := range records {
+			fmt.Fprintln(tw, strings.Join(r.strings(), "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		// Empty trailing columns are padded with spaces, drop them
+		for _, line := range strings.SplitAfter(b.String(), "\n") {
+			if line == "" {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, strings.TrimRight(line, " \n")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("format %q is not machine readable", format)
+}
+
+func (r Record) strings() []string {
+	values := make([]string, 0, len(r))
+	for _, f := range r {
+		values = append(values, toString(f.Value))
+	}
+	return values
+}
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func yamlValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		// JSON strings are valid double-quoted YAML scalars
+		b, _ := json.Marshal(v)
+		return string(b)
+	case int, int64, bool:
+		return fmt.Sprint(v)
+	case nil:
+		return "null"
+	default:
+		return strconv.Quote(fmt.Sprint(v))
+	}
+}
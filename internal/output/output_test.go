@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+var keys = []string{"name", "port", "direct"}
+
+var records = []Record{
+	{{Key: "name", Value: "web"}, {Key: "port", Value: 22}, {Key: "direct", Value: false}},
+	{{Key: "name", Value: `say "hi", bye`}, {Key: "port", Value: 2222}, {Key: "direct", Value: true}},
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format  Format
+		records []Record
+		want    string
+	}{
+		{JSON, records, `[
+  {
+    "name": "web",
+    "port": 22,
+    "direct": false
+  },
+  {
+    "name": "say \"hi\", bye",
+    "port": 2222,
+    "direct": true
+  }
+]
+`},
+		{JSON, nil, "[]\n"},
+		{YAML, records, `- name: "web"
+  port: 22
+  direct: false
+- name: "say \"hi\", bye"
+  port: 2222
+  direct: true
+`},
+		{YAML, nil, "[]\n"},
+		{CSV, records, "name,port,direct\nweb,22,false\n\"say \"\"hi\"\", bye\",2222,true\n"},
+		{TSV, records, "name\tport\tdirect\nweb\t22\tfalse\n\"say \"\"hi\"\", bye\"\t2222\ttrue\n"},
+		{CSV, nil, "name,port,direct\n"},
+		{Plain, records, "NAME           PORT  DIRECT\nweb            22    false\nsay \"hi\", bye  2222  true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, tt.format, keys, tt.records); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Write(%s) =\n%q\nwant\n%q", tt.format, b.String(), tt.want)
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, Table, keys, records); err == nil {
+		t.Errorf("Write() with the table format should fail")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"json", "YAML", "csv", "tsv", "plain", "table"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", name, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("ParseFormat(xml) should fail")
+	}
+
+	// go test doesn't run with stdout attached to a terminal
+	if f, _ := ParseFormat(""); f != Plain {
+		t.Errorf("ParseFormat(\"\") = %v, want plain when stdout is not a terminal", f)
+	}
+}
@@ -0,0 +1,96 @@
+package probe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache keeps probe results between runs of ggh in ~/.ggh/probe.json
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	results map[string]Result
+}
+
+// LoadCache reads the cached results, a negative ttl disables the cache
+func LoadCache(ttl time.Duration) *Cache {
+	c := &Cache{ttl: ttl, results: map[string]Result{}}
+	if ttl < 0 {
+		return c
+	}
+
+	content, err := os.ReadFile(getFileLocation())
+	if err != nil || len(content) == 0 {
+		return c
+	}
+
+	var results []Result
+	if err := json.Unmarshal(content, &results); err != nil {
+		return c
+	}
+	for _, r := range results {
+		c.results[r.Addr] = r
+	}
+	return c
+}
+
+// Get returns the result for addr if it was checked less than ttl ago
+func (c *Cache) Get(addr string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.results[addr]
+	if !ok || c.ttl < 0 || time.Since(r.Checked) > c.ttl {
+		return Result{}, false
+	}
+	return r, true
+}
+
+// Put stores the result of a probe
+func (c *Cache) Put(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results[r.Addr] = r
+}
+
+// Save writes the fresh results to disk, dropping the expired ones
+func (c *Cache) Save() error {
+	if c.ttl < 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	results := make([]Result, 0, len(c.results))
+	for _, r := range c.results {
+		if time.Since(r.Checked) <= c.ttl {
+			results = append(results, r)
+		}
+	}
+	c.mu.Unlock()
+
+	b, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getFileLocation(), b, 0644)
+}
+
+func getFileLocation() string {
+	userHomeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return ""
+	}
+
+	gghConfigDir := filepath.Join(userHomeDir, ".ggh")
+
+	if err := os.MkdirAll(gghConfigDir, 0700); err != nil {
+		return ""
+	}
+
+	return filepath.Join(gghConfigDir, "probe.json")
+}
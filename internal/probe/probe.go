@@ -0,0 +1,129 @@
+package probe
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+const (
+	// Pending is shown while a host hasn't answered yet
+	Pending = "…"
+
+	defaultConcurrency = 8
+	defaultTimeout     = time.Second
+	defaultCacheTTL    = time.Minute
+)
+
+// Result is the outcome of probing one address
+type Result struct {
+	Addr    string        `json:"addr"`
+	Up      bool          `json:"up"`
+	RTT     time.Duration `json:"rtt"`
+	Banner  string        `json:"banner,omitempty"`
+	Checked time.Time     `json:"checked"`
+}
+
+// Status renders the result for the Status column
+func (r Result) Status() string {
+	if !r.Up {
+		return "✘ down"
+	}
+	if r.RTT < time.Millisecond {
+		return "✔ <1ms"
+	}
+	return fmt.Sprintf("✔ %dms", r.RTT.Milliseconds())
+}
+
+// Addr returns the address to probe for a host, the port defaults to 22
+func Addr(host, port string) string {
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Probe opens a TCP connection to addr and measures how long it took. With
+// banner the first line the server sends is read, a server that accepts the
+// connection but sends no SSH banner within the timeout is down.
+func Probe(addr string, timeout time.Duration, banner bool) Result {
+	r := Result{Addr: addr, Checked: time.Now()}
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return r
+	}
+	defer conn.Close()
+	r.RTT = time.Since(start)
+
+	if banner {
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, "SSH-") {
+			return r
+		}
+		r.Banner = strings.TrimSpace(line)
+	}
+
+	r.Up = true
+	return r
+}
+
+// Prober probes hosts with a limited concurrency, reusing cached results
+type Prober struct {
+	sem     chan struct{}
+	timeout time.Duration
+	banner  bool
+	cache   *Cache
+}
+
+// New returns a prober configured by s, with its cache loaded from disk
+func New(s settings.Probe) *Prober {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	timeout := time.Duration(s.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ttl := time.Duration(s.CacheSeconds) * time.Second
+	if s.CacheSeconds == 0 {
+		ttl = defaultCacheTTL
+	}
+
+	return &Prober{
+		sem:     make(chan struct{}, concurrency),
+		timeout: timeout,
+		banner:  s.Banner,
+		cache:   LoadCache(ttl),
+	}
+}
+
+// Cached returns the result for addr if it is still fresh
+func (p *Prober) Cached(addr string) (Result, bool) {
+	return p.cache.Get(addr)
+}
+
+// Probe probes addr, waiting for a free slot first. It is safe to call from
+// many goroutines.
+func (p *Prober) Probe(addr string) Result {
+	p.sem <- struct{}{}
+	defer func() { <-p.sem }()
+
+	r := Probe(addr, p.timeout, p.banner)
+	p.cache.Put(r)
+	return r
+}
+
+// Save writes the cached results to disk
+func (p *Prober) Save() error {
+	return p.cache.Save()
+}
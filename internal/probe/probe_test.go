@@ -0,0 +1,161 @@
+package probe
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+// listen starts a listener that writes greeting to every connection
+func listen(t *testing.T, greeting string) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			if greeting != "" {
+				_, _ = conn.Write([]byte(greeting))
+			}
+			time.Sleep(200 * time.Millisecond)
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// closedAddr returns the address of a port nothing listens on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestProbe(t *testing.T) {
+	sshAddr := listen(t, "SSH-2.0-OpenSSH_9.6\r\n")
+	silentAddr := listen(t, "")
+	httpAddr := listen(t, "HTTP/1.1 400 Bad Request\r\n")
+	closed := closedAddr(t)
+
+	tests := []struct {
+		name   string
+		addr   string
+		banner bool
+		up     bool
+	}{
+		{"open port", silentAddr, false, true},
+		{"closed port", closed, false, false},
+		{"ssh banner", sshAddr, true, true},
+		{"no banner", silentAddr, true, false},
+		{"not ssh", httpAddr, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Probe(tt.addr, 100*time.Millisecond, tt.banner)
+			if r.Up != tt.up {
+				t.Errorf("Probe(%s) up = %v, want %v", tt.addr, r.Up, tt.up)
+			}
+			if r.Addr != tt.addr || r.Checked.IsZero() {
+				t.Errorf("Probe(%s) = %+v", tt.addr, r)
+			}
+		})
+	}
+
+	if r := Probe(sshAddr, time.Second, true); r.Banner != "SSH-2.0-OpenSSH_9.6" {
+		t.Errorf("banner = %q", r.Banner)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   string
+	}{
+		{Result{Up: true, RTT: 12 * time.Millisecond}, "✔ 12ms"},
+		{Result{Up: true, RTT: 300 * time.Microsecond}, "✔ <1ms"},
+		{Result{Up: false}, "✘ down"},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Status(); got != tt.want {
+			t.Errorf("Status() = %q, want %q", got, tt.want)
+		}
+	}
+
+	if got := Addr("10.0.0.1", ""); got != "10.0.0.1:22" {
+		t.Errorf("Addr() = %q", got)
+	}
+	if got := Addr("::1", "2222"); got != "[::1]:2222" {
+		t.Errorf("Addr() = %q", got)
+	}
+}
+
+func TestProber(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	up := listen(t, "")
+	down := closedAddr(t)
+
+	p := New(settings.Probe{Enabled: true, Concurrency: 2, TimeoutMs: 200})
+	var wg sync.WaitGroup
+	for _, addr := range []string{up, down, up, down} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Probe(addr)
+		}()
+	}
+	wg.Wait()
+
+	if r, ok := p.Cached(up); !ok || !r.Up {
+		t.Errorf("Cached(up) = %+v, %v", r, ok)
+	}
+	if r, ok := p.Cached(down); !ok || r.Up {
+		t.Errorf("Cached(down) = %+v, %v", r, ok)
+	}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new run reuses the saved results
+	again := New(settings.Probe{})
+	if _, ok := again.Cached(up); !ok {
+		t.Errorf("saved result of %s not loaded", up)
+	}
+
+	// a negative cache time never reuses them
+	uncached := New(settings.Probe{CacheSeconds: -1})
+	if _, ok := uncached.Cached(up); ok {
+		t.Errorf("cache disabled but %s was cached", up)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	c := LoadCache(time.Minute)
+	c.Put(Result{Addr: "old:22", Up: true, Checked: time.Now().Add(-2 * time.Minute)})
+	c.Put(Result{Addr: "new:22", Up: true, Checked: time.Now()})
+
+	if _, ok := c.Get("old:22"); ok {
+		t.Errorf("expired result returned")
+	}
+	if _, ok := c.Get("new:22"); !ok {
+		t.Errorf("fresh result not returned")
+	}
+}
@@ -0,0 +1,183 @@
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Header is the first line of an asciicast v2 file
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is one line after the header: output ("o") or a resize ("r")
+type Event struct {
+	Time float64
+	Code string
+	Data string
+}
+
+// Writer writes terminal output as asciicast v2 events, timed from the
+// moment it was created
+type Writer struct {
+	mu      sync.Mutex
+	out     io.Writer
+	start   time.Time
+	now     func() time.Time
+	pending []byte
+}
+
+// NewWriter writes the header to out and returns a writer for the events
+func NewWriter(out io.Writer, h Header) (*Writer, error) {
+	h.Version = 2
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(out, "%s\n", b); err != nil {
+		return nil, err
+	}
+	return &Writer{out: out, start: time.Now(), now: time.Now}, nil
+}
+
+// Write records p as output. A multi-byte character split between two
+// writes is kept until it is complete, the events are valid UTF-8.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.pending, p...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	w.pending = append([]byte(nil), data[cut:]...)
+
+	if cut > 0 {
+		if err := w.event("o", string(data[:cut])); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size
+func (w *Writer) Resize(width, height int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// Flush writes the incomplete character left by the last write, if any
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+	data := string(w.pending)
+	w.pending = nil
+	return w.event("o", data)
+}
+
+func (w *Writer) event(code, data string) error {
+	elapsed := float64(w.now().Sub(w.start).Microseconds()) / 1e6
+	b, err := json.Marshal([]any{elapsed, code, data})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w.out, "%s\n", b)
+	return err
+}
+
+// Read parses an asciicast v2 file
+func Read(r io.Reader) (Header, []Event, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+
+	var h Header
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return h, nil, err
+		}
+		return h, nil, fmt.Errorf("empty recording")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil {
+		return h, nil, fmt.Errorf("bad asciicast header: %w", err)
+	}
+	if h.Version != 2 {
+		return h, nil, fmt.Errorf("unsupported asciicast version %d", h.Version)
+	}
+
+	var events []Event
+	for line := 2; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var raw []json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil || len(raw) != 3 {
+			return h, events, fmt.Errorf("bad asciicast event on line %d", line)
+		}
+
+		var e Event
+		var t json.Number
+		if json.Unmarshal(raw[0], &t) != nil || json.Unmarshal(raw[1], &e.Code) != nil || json.Unmarshal(raw[2], &e.Data) != nil {
+			return h, events, fmt.Errorf("bad asciicast event on line %d", line)
+		}
+		e.Time, _ = strconv.ParseFloat(string(t), 64)
+		events = append(events, e)
+	}
+	return h, events, scanner.Err()
+}
+
+// PlayOptions change the pace of a replay
+type PlayOptions struct {
+	// Speed multiplies the speed of the replay, 1 when unset
+	Speed float64
+	// MaxIdle caps the pauses between two events, unlimited when unset
+	MaxIdle time.Duration
+}
+
+// Play writes the output events to out with their original timing
+func Play(out io.Writer, events []Event, opts PlayOptions) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	last := 0.0
+	for _, e := range events {
+		if e.Code != "o" {
+			continue
+		}
+		pause := time.Duration((e.Time - last) * float64(time.Second))
+		if opts.MaxIdle > 0 && pause > opts.MaxIdle {
+			pause = opts.MaxIdle
+		}
+		time.Sleep(time.Duration(float64(pause) / speed))
+		last = e.Time
+
+		if _, err := io.WriteString(out, e.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
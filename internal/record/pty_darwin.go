@@ -0,0 +1,43 @@
+package record
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal pair
+func openPTY() (master *os.File, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fd := master.Fd()
+	name := make([]byte, 128)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, unix.TIOCPTYGRANT, 0); errno != 0 {
+		master.Close()
+		return nil, nil, errno
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, unix.TIOCPTYUNLK, 0); errno != 0 {
+		master.Close()
+		return nil, nil, errno
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); errno != 0 {
+		master.Close()
+		return nil, nil, errno
+	}
+
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	slave, err = os.OpenFile(string(name), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package record
+
+import "os/exec"
+
+// Supported reports whether sessions can be recorded on this system
+const Supported = false
+
+func runPTY(cmd *exec.Cmd, cast *Writer) (int, error) {
+	return 1, ErrUnsupported
+}
@@ -0,0 +1,46 @@
+//go:build linux || darwin
+
+package record
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// the command sees a terminal, as ssh will
+	cmd := exec.Command("sh", "-c", "test -t 1 && printf 'on a tty\\n'; exit 3")
+	code, path, err := Session(cmd, "stage")
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+	if code != 3 {
+		t.Errorf("Session() exit code = %d, want 3", code)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	h, events, err := Read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Title != "stage" || h.Width == 0 {
+		t.Errorf("header = %+v", h)
+	}
+
+	var output strings.Builder
+	for _, e := range events {
+		output.WriteString(e.Data)
+	}
+	if !strings.Contains(output.String(), "on a tty") {
+		t.Errorf("recorded %q", output.String())
+	}
+}
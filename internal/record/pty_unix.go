@@ -0,0 +1,111 @@
+//go:build linux || darwin
+
+package record
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/cancelreader"
+	"golang.org/x/sys/unix"
+)
+
+// Supported reports whether sessions can be recorded on this system
+const Supported = true
+
+// runPTY runs cmd with a pseudo-terminal as its terminal, forwarding the
+// user's input to it and its output both to the user and to cast. A failed
+// write to cast stops the recording, not the session: the error is returned
+// once cmd is done.
+func runPTY(cmd *exec.Cmd, cast *Writer) (int, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return 1, err
+	}
+	defer master.Close()
+
+	if width, height, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		setSize(master, width, height)
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		slave.Close()
+		return 1, err
+	}
+	slave.Close()
+
+	// the keys go to the remote side as typed, it echoes them back
+	if term.IsTerminal(os.Stdin.Fd()) {
+		if state, err := term.MakeRaw(os.Stdin.Fd()); err == nil {
+			defer term.Restore(os.Stdin.Fd(), state)
+		}
+	}
+
+	// follow the size of the user's terminal
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer func() {
+		signal.Stop(resize)
+		close(resize)
+	}()
+	go func() {
+		for range resize {
+			if width, height, err := term.GetSize(os.Stdout.Fd()); err == nil {
+				setSize(master, width, height)
+				_ = cast.Resize(width, height)
+			}
+		}
+	}()
+
+	// stop reading the input when the session ends, so it isn't lost
+	if stdin, err := cancelreader.NewReader(os.Stdin); err == nil {
+		defer stdin.Cancel()
+		go func() { _, _ = io.Copy(master, stdin) }()
+	} else {
+		go func() { _, _ = io.Copy(master, os.Stdin) }()
+	}
+
+	recording := &bestEffort{w: cast}
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.MultiWriter(os.Stdout, recording), master)
+		close(done)
+	}()
+
+	err = cmd.Wait()
+	// the output ends when every process holding the terminal is gone, a
+	// background process keeping it open doesn't hold up ggh
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+
+	code := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		return 1, err
+	}
+	if err := recording.Err(); err != nil {
+		return code, fmt.Errorf("the recording stopped, the rest of the session wasn't saved: %w", err)
+	}
+	return code, nil
+}
+
+func setSize(master *os.File, width, height int) {
+	_ = unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Col: uint16(width),
+		Row: uint16(height),
+	})
+}
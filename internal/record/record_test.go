@@ -0,0 +1,212 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header{Width: 100, Height: 30, Timestamp: 1700000000, Title: "stage"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := w.start
+	w.now = func() time.Time { return clock }
+
+	euro := []byte("€") // three bytes, split over two writes
+	clock = clock.Add(500 * time.Millisecond)
+	_, _ = w.Write([]byte("hello "))
+	_, _ = w.Write(append([]byte("price: 5"), euro[:2]...))
+	clock = clock.Add(time.Second)
+	_, _ = w.Write(append(euro[2:], '\n'))
+	_ = w.Resize(120, 40)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, events, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != 2 || h.Width != 100 || h.Height != 30 || h.Title != "stage" {
+		t.Errorf("header = %+v", h)
+	}
+
+	want := []Event{
+		{0.5, "o", "hello "},
+		{0.5, "o", "price: 5"},
+		{1.5, "o", "€\n"},
+		{1.5, "r", "120x40"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		events  int
+		wantErr string
+	}{
+		{"valid", `{"version": 2, "width": 80, "height": 24}` + "\n" + `[0.1, "o", "a"]` + "\n\n" + `[0.2, "o", "b"]` + "\n", 2, ""},
+		{"empty", "", 0, "empty recording"},
+		{"version 1", `{"version": 1}` + "\n", 0, "unsupported asciicast version 1"},
+		{"bad event", `{"version": 2}` + "\n" + `[0.1, "o", "a"]` + "\n" + `{"oops": 1}` + "\n", 1, "line 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, events, err := Read(strings.NewReader(tt.content))
+			if len(events) != tt.events {
+				t.Errorf("Read() returned %d events, want %d", len(events), tt.events)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Read() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Read() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlay(t *testing.T) {
+	events := []Event{
+		{0.01, "o", "one "},
+		{0.02, "r", "100x40"},
+		{60, "o", "two"},
+	}
+
+	var out bytes.Buffer
+	start := time.Now()
+	if err := Play(&out, events, PlayOptions{Speed: 2, MaxIdle: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "one two" {
+		t.Errorf("Play() wrote %q", out.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Play() took %v, the idle time should be capped", elapsed)
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if list, err := List(); err != nil || len(list) != 0 {
+		t.Fatalf("List() = %v, %v without recordings", list, err)
+	}
+
+	older := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	for _, rec := range []struct {
+		host  string
+		start time.Time
+	}{{"web@10.0.0.1", older}, {"stage", newer}} {
+		f, err := create(rec.host, rec.start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, _ := NewWriter(f, Header{Width: 80, Height: 24, Timestamp: rec.start.Unix(), Title: rec.host})
+		w.now = func() time.Time { return w.start.Add(90 * time.Second) }
+		_, _ = w.Write([]byte("bye\n"))
+		f.Close()
+	}
+
+	list, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Host != "stage" || list[1].Host != "web@10.0.0.1" {
+		t.Fatalf("List() = %+v", list)
+	}
+	if list[1].Name != "web-10.0.0.1_20240101-100000.cast" || list[1].Duration != 90*time.Second {
+		t.Errorf("recording = %+v", list[1])
+	}
+
+	// a second session to the host in the same second gets its own file
+	f, err := create("stage", newer)
+	if err != nil {
+		t.Fatalf("create() of a second recording error = %v", err)
+	}
+	f.Close()
+	if filepath.Base(f.Name()) != "stage_20240101-110000-2.cast" {
+		t.Errorf("second recording = %s", f.Name())
+	}
+
+	path, err := Find("stage_20240101-110000")
+	if err != nil || filepath.Base(path) != "stage_20240101-110000.cast" {
+		t.Errorf("Find() = %q, %v", path, err)
+	}
+	if _, err := Find("nope"); err == nil {
+		t.Errorf("Find() of an unknown recording should fail")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("recording mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
+
+// failAfter takes n writes, then fails every other one
+type failAfter struct {
+	n      int
+	calls  int
+	writes []string
+}
+
+func (f *failAfter) Write(p []byte) (int, error) {
+	f.calls++
+	if len(f.writes) == f.n {
+		return 0, errors.New("disk full")
+	}
+	f.writes = append(f.writes, string(p))
+	return len(p), nil
+}
+
+func TestBestEffort(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		writes []string
+		calls  int
+		err    bool
+	}{
+		{"all written", 3, []string{"a", "b", "c"}, 3, false},
+		{"stops at the first failure", 1, []string{"a"}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &failAfter{n: tt.n}
+			w := &bestEffort{w: out}
+			for _, s := range []string{"a", "b", "c"} {
+				if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v, want %d, nil", s, n, err, len(s))
+				}
+			}
+			if strings.Join(out.writes, "") != strings.Join(tt.writes, "") {
+				t.Errorf("written %q, want %q", out.writes, tt.writes)
+			}
+			if out.calls != tt.calls {
+				t.Errorf("%d writes tried, want %d", out.calls, tt.calls)
+			}
+			if err := w.Err(); (err != nil) != tt.err {
+				t.Errorf("Err() = %v, want an error: %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package record
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// ErrUnsupported is returned when sessions can't be recorded on this system
+var ErrUnsupported = errors.New("session recording is not supported on this system")
+
+// Session runs cmd under a pseudo-terminal, records what it prints to a new
+// file named after host and returns the exit code of cmd and the file
+func Session(cmd *exec.Cmd, host string) (int, string, error) {
+	if !Supported {
+		return 1, "", ErrUnsupported
+	}
+
+	width, height := terminalSize()
+	start := time.Now()
+	f, err := create(host, start)
+	if err != nil {
+		return 1, "", fmt.Errorf("can't create the recording: %w", err)
+	}
+	defer f.Close()
+
+	cast, err := NewWriter(f, Header{
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Title:     host,
+		Env:       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	})
+	if err != nil {
+		return 1, f.Name(), err
+	}
+
+	code, err := runPTY(cmd, cast)
+	if flushErr := cast.Flush(); err == nil {
+		err = flushErr
+	}
+	return code, f.Name(), err
+}
+
+// terminalSize returns the size of the user's terminal, 80x24 when it
+// isn't one
+func terminalSize() (int, int) {
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// bestEffort writes to w until a write fails, and from then on drops what
+// it is given. It never fails itself, so copying the output of a session to
+// both the terminal and the recording keeps the terminal going.
+type bestEffort struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+func (b *bestEffort) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err == nil {
+		_, b.err = b.w.Write(p)
+	}
+	return len(p), nil
+}
+
+// Err returns the error of the write that failed, if any
+func (b *bestEffort) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.err
+}
@@ -0,0 +1,163 @@
+package record
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/output"
+)
+
+const timeLayout = "20060102-150405"
+
+// Recording is a session saved in the recordings directory
+type Recording struct {
+	Name     string
+	Path     string
+	Host     string
+	Start    time.Time
+	Duration time.Duration
+	Size     int64
+}
+
+// Dir returns ~/.ggh/recordings
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ggh", "recordings"), nil
+}
+
+// fileName returns the name of a recording of host started at start. n
+// tells apart the recordings started in the same second, from 2 on.
+func fileName(host string, start time.Time, n int) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '-'
+	}, host)
+	if n > 1 {
+		return fmt.Sprintf("%s_%s-%d.cast", safe, start.Format(timeLayout), n)
+	}
+	return fmt.Sprintf("%s_%s.cast", safe, start.Format(timeLayout))
+}
+
+// create opens a new recording file for host, readable by the user only.
+// An existing recording is never overwritten.
+func create(host string, start time.Time) (*os.File, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	for n := 1; ; n++ {
+		f, err := os.OpenFile(filepath.Join(dir, fileName(host, start, n)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+// List returns the recordings, the most recent first
+func List() ([]Recording, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Recording
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".cast" {
+			continue
+		}
+		r, err := load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		list = append(list, r)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Start.After(list[j].Start) })
+	return list, nil
+}
+
+// Find returns the path of a recording given by name, with or without
+// .cast, or by path
+func Find(name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range []string{name, name + ".cast"} {
+		path := filepath.Join(dir, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("recording not found: %s", name)
+}
+
+func load(path string) (Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Recording{}, err
+	}
+	defer f.Close()
+
+	h, events, err := Read(f)
+	if err != nil && len(events) == 0 {
+		return Recording{}, err
+	}
+
+	r := Recording{
+		Name:  filepath.Base(path),
+		Path:  path,
+		Host:  h.Title,
+		Start: time.Unix(h.Timestamp, 0),
+	}
+	if len(events) > 0 {
+		r.Duration = time.Duration(events[len(events)-1].Time * float64(time.Second))
+	}
+	if info, err := f.Stat(); err == nil {
+		r.Size = info.Size()
+	}
+	return r, nil
+}
+
+// RecordKeys are the fields of a recording in machine readable output
+var RecordKeys = []string{"name", "host", "start", "duration_seconds", "size", "path"}
+
+// Records converts recordings to output records
+func Records(list []Recording) []output.Record {
+	records := make([]output.Record, 0, len(list))
+	for _, r := range list {
+		records = append(records, output.Record{
+			{Key: "name", Value: r.Name},
+			{Key: "host", Value: r.Host},
+			{Key: "start", Value: r.Start.Format(time.RFC3339)},
+			{Key: "duration_seconds", Value: int64(r.Duration.Seconds())},
+			{Key: "size", Value: r.Size},
+			{Key: "path", Value: r.Path},
+		})
+	}
+	return records
+}
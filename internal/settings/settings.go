@@ -2,27 +2,174 @@ package settings
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync/atomic"
 )
 
 type Settings struct {
-	Fullscreen bool `json:"fullscreen"`
+	Fullscreen bool      `json:"fullscreen"`
+	Probe      Probe     `json:"probe"`
+	Transport  Transport `json:"transport"`
+	Hooks      []Hook    `json:"hooks,omitempty"`
+	Recording  Recording `json:"recording"`
+	Multiplex  Multiplex `json:"multiplex"`
+	Agent      Agent     `json:"agent"`
+	Guards     []Guard   `json:"guards,omitempty"`
+	// Theme is the name of a built-in theme or of one of Themes
+	Theme  string               `json:"theme,omitempty"`
+	Themes map[string]UserTheme `json:"themes,omitempty"`
+	Keys   Keys                 `json:"keys"`
+	// Tables lays out the host selectors, by config and history
+	Tables map[string]Table `json:"tables,omitempty"`
+
+	// err is why settings.json couldn't be parsed
+	err error
+}
+
+// Err returns why settings.json couldn't be parsed, the settings are
+// empty then
+func (s Settings) Err() error {
+	return s.err
+}
+
+// Table chooses the columns of a host selector and how it is sorted
+type Table struct {
+	// Columns are the IDs of the columns shown, in order, like name, host
+	// or last_login
+	Columns []string `json:"columns,omitempty"`
+	// Sort is the ID of the column the rows are sorted by, descending with
+	// a leading -, like -count. Unset keeps the order of the list.
+	Sort string `json:"sort,omitempty"`
+}
+
+// Keys remaps the keys of the interactive views
+type Keys struct {
+	// Preset is the keymap the bindings change: default, vim or emacs
+	Preset string `json:"preset,omitempty"`
+	// Bindings maps actions, like hosts.delete or list.filter, to the keys
+	// running them instead of the keys of the preset
+	Bindings map[string][]string `json:"bindings,omitempty"`
+}
+
+// UserTheme is a color scheme of the user, built on a built-in theme
+type UserTheme struct {
+	// Base is the built-in theme giving the colors not set, auto when unset
+	Base string `json:"base,omitempty"`
+	// Colors maps roles like accent or error to ANSI numbers like "57" or
+	// hex colors like "#FF0060"
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+// Guard protects hosts like production servers: ggh shows a banner and
+// asks to type the name of the host before connecting. The restrictions
+// refuse or change the connection, --i-know bypasses the guard.
+type Guard struct {
+	// Match are globs matched against the alias and the HostName, or
+	// tag:GLOB matched against the tags of the host
+	Match []string `json:"match"`
+	// Label names the hosts in the banner, production when unset
+	Label string `json:"label,omitempty"`
+	// NoAgentForwarding refuses -A and turns forwarding off otherwise
+	NoAgentForwarding bool `json:"no_agent_forwarding,omitempty"`
+	// RequireRecording records every session, as --record does
+	RequireRecording bool `json:"require_recording,omitempty"`
+	// Hours is the local time window connections are allowed in, like
+	// 09:00-18:00. A window can end the next day, 22:00-06:00.
+	Hours string `json:"hours,omitempty"`
+}
+
+// Agent checks before connecting that ssh-agent holds the key of the host
+type Agent struct {
+	// Enabled offers to ssh-add the key when it isn't loaded
+	Enabled bool `json:"enabled"`
+	// Lifetime is how long the keys added by ggh stay loaded, as ssh-add -t
+	// takes it, like 1h. Unset keeps them until the agent stops.
+	Lifetime string `json:"lifetime,omitempty"`
+}
+
+// Multiplex shares one ssh connection between the sessions to a host
+type Multiplex struct {
+	// Hosts are globs matched against the alias and the HostName
+	Hosts []string `json:"hosts,omitempty"`
+	// Persist is how long the master connection stays open after the last
+	// session, as ssh's ControlPersist, 10m when unset
+	Persist string `json:"persist,omitempty"`
+}
+
+// Recording chooses the hosts whose sessions are always recorded
+type Recording struct {
+	// Hosts are globs matched against the alias and the HostName
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// Hook runs shell commands before ssh starts and after it exits. Every hook
+// whose Match glob matches the alias or HostName runs, in order, and a hook
+// without Match runs for every host.
+type Hook struct {
+	Match          string `json:"match,omitempty"`
+	PreConnect     string `json:"pre_connect,omitempty"`
+	PostDisconnect string `json:"post_disconnect,omitempty"`
+}
+
+// Probe configures the reachability column of the selectors
+type Probe struct {
+	// Enabled adds the Status column and probes the hosts in the background
+	Enabled bool `json:"enabled"`
+	// Concurrency is the number of hosts probed at once, 8 when unset
+	Concurrency int `json:"concurrency,omitempty"`
+	// TimeoutMs is how long to wait for a host, 1000 when unset
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+	// CacheSeconds is how long a result is reused, 60 when unset and
+	// negative to always probe
+	CacheSeconds int `json:"cache_seconds,omitempty"`
+	// Banner reads the SSH banner after connecting, so ports that accept
+	// connections but don't speak SSH are shown
+	Banner bool `json:"banner,omitempty"`
+}
+
+// Transport is the program ggh runs instead of ssh, for example a wrapper
+// adding audit logs
+type Transport struct {
+	// Binary is a path or a name looked up in PATH, ssh when unset
+	Binary string `json:"binary,omitempty"`
+	// Args are passed before the arguments of every connection
+	Args []string `json:"args,omitempty"`
+	// Env is added to the environment of the binary
+	Env map[string]string `json:"env,omitempty"`
+	// Hosts override the transport for some hosts, the first match wins
+	Hosts []HostTransport `json:"hosts,omitempty"`
+}
+
+// HostTransport overrides the transport for the hosts whose alias or
+// HostName match the Match glob. Binary and Args replace the global ones
+// when set, Env is added to the global one.
+type HostTransport struct {
+	Match  string            `json:"match"`
+	Binary string            `json:"binary,omitempty"`
+	Args   []string          `json:"args,omitempty"`
+	Env    map[string]string `json:"env,omitempty"`
 }
 
 var S atomic.Value
 
+// loaded is closed once the settings file has been read
+var loaded = make(chan struct{})
+
 func init() {
 	S.Store(Settings{})
 
 	go func() {
 		s := fetchWithDefaultFile()
 		S.Store(s)
+		close(loaded)
 	}()
 }
 
+// Get returns the settings, waiting for the settings file to be read
 func Get() Settings {
+	<-loaded
 	if s, ok := S.Load().(Settings); ok {
 		return s
 	}
@@ -30,10 +177,12 @@ func Get() Settings {
 }
 
 func fetchWithDefaultFile() Settings {
-	return fetch(getFile())
+	return Parse(getFile())
 }
 
-func fetch(file []byte) Settings {
+// Parse reads the content of settings.json. Invalid content gives empty
+// settings with the error in Err.
+func Parse(file []byte) Settings {
 	var s Settings
 
 	if len(file) == 0 {
@@ -42,13 +191,17 @@ func fetch(file []byte) Settings {
 
 	err := json.Unmarshal(file, &s)
 	if err != nil {
-		return Settings{}
+		return Settings{err: fmt.Errorf("~/.ggh/settings.json is invalid: %w", err)}
 	}
 
 	return s
 }
 
 func Save(s Settings) error {
+	// saving would overwrite the file with the empty settings
+	if s.err != nil {
+		return s.err
+	}
 	b, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
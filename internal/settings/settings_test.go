@@ -28,3 +28,17 @@ func TestMarshal(t *testing.T) {
 	}
 	time.Sleep(100 * time.Millisecond) // Allow time for file operations
 }
+
+func TestParseInvalid(t *testing.T) {
+	s := Parse([]byte(`{"guards": [{"match": ["prod-*"]}`))
+	if s.Err() == nil || len(s.Guards) != 0 {
+		t.Fatalf("Parse() of invalid JSON = %+v, %v, want empty settings and an error", s, s.Err())
+	}
+	if err := Save(s); err == nil {
+		t.Errorf("Save() of unparsed settings should fail instead of emptying the file")
+	}
+
+	if s := Parse([]byte(`{"fullscreen": true}`)); s.Err() != nil || !s.Fullscreen {
+		t.Errorf("Parse() = %+v, %v", s, s.Err())
+	}
+}
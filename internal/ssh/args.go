@@ -0,0 +1,151 @@
+package ssh
+
+import (
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/keys"
+)
+
+// valueFlags are the ssh options that take an argument
+const valueFlags = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// parsedArgs is what ggh reads from ssh arguments
+type parsedArgs struct {
+	dest     string
+	user     string
+	port     string
+	key      string
+	forwards []string
+	// forwardAgent is set by -A and -o ForwardAgent, cleared by -a
+	forwardAgent bool
+	// agentSet is set when the arguments choose the agent forwarding,
+	// otherwise the ssh config does
+	agentSet bool
+}
+
+// parseArgs reads the destination, user, port, key, port forwards and agent
+// forwarding of ssh arguments. Options can be grouped, -At, and a value can
+// be attached, -p22.
+func parseArgs(args []string) parsedArgs {
+	var p parsedArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" {
+			continue
+		}
+		if arg == "--" {
+			if i+1 < len(args) {
+				p.setDest(args[i+1])
+			}
+			return p
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			p.setDest(arg)
+			return p
+		}
+
+		for j := 1; j < len(arg); j++ {
+			switch arg[j] {
+			case 'A':
+				p.forwardAgent, p.agentSet = true, true
+			case 'a':
+				p.forwardAgent, p.agentSet = false, true
+			}
+			if strings.IndexByte(valueFlags, arg[j]) < 0 {
+				continue
+			}
+			value := arg[j+1:]
+			if value == "" && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			switch arg[j] {
+			case 'p':
+				p.port = value
+			case 'l':
+				p.user = value
+			case 'i':
+				if p.key == "" {
+					p.key = value
+				}
+			case 'L', 'R', 'D':
+				p.forwards = append(p.forwards, "-"+string(arg[j])+" "+value)
+			case 'o':
+				// ForwardAgent=yes, "ForwardAgent yes" or a socket path
+				name, v, _ := strings.Cut(strings.Replace(value, "=", " ", 1), " ")
+				if strings.EqualFold(name, "ForwardAgent") {
+					p.forwardAgent, p.agentSet = forwardsAgent(strings.TrimSpace(v)), true
+				}
+			}
+			break
+		}
+	}
+	return p
+}
+
+// forwardsAgent reports whether a ForwardAgent value, yes or a socket
+// path, forwards the agent
+func forwardsAgent(value string) bool {
+	return value != "" && !strings.EqualFold(value, "no")
+}
+
+func (p *parsedArgs) setDest(dest string) {
+	dest = strings.TrimPrefix(dest, "ssh://")
+	if at := strings.LastIndex(dest, "@"); at >= 0 {
+		p.user = dest[:at]
+		dest = dest[at+1:]
+	}
+	p.dest = dest
+}
+
+// Destination returns the host of ssh arguments, without the user
+func Destination(args []string) string {
+	return parseArgs(args).dest
+}
+
+// Connection is what ggh knows of the host ssh connects to
+type Connection struct {
+	// Alias is the ssh config alias, empty for direct connections
+	Alias string
+	Host  string
+	User  string
+	Port  string
+	// Key is the identity file given with -i or in the ssh config
+	Key string
+	// Tunnels are the port forwards, as ssh options like "-L 8080:db:5432"
+	Tunnels []string
+	// ForwardAgent is set when the arguments, or the ssh config when they
+	// don't say, ask for agent forwarding
+	ForwardAgent bool
+}
+
+// ConnectionFor resolves the connection made with args, completing it from
+// the ssh config when the destination is an alias
+func ConnectionFor(args []string) Connection {
+	p := parseArgs(args)
+	conn := Connection{Host: p.dest, User: p.user, Port: p.port, Key: keys.ArgPath(p.key), Tunnels: p.forwards, ForwardAgent: p.forwardAgent}
+
+	if c, err := config.GetConfig(p.dest); err == nil && c.Name != "" {
+		conn.Alias = c.Name
+		if c.Host != "" {
+			conn.Host = c.Host
+		}
+		if conn.User == "" {
+			conn.User = c.User
+		}
+		if conn.Port == "" {
+			conn.Port = c.Port
+		}
+		if conn.Key == "" {
+			conn.Key = c.Key
+		}
+	}
+	if conn.Port == "" {
+		conn.Port = "22"
+	}
+	if !p.agentSet && p.dest != "" {
+		conn.ForwardAgent = forwardsAgent(config.Option(p.dest, "ForwardAgent"))
+	}
+	return conn
+}
@@ -0,0 +1,236 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/MrLonely14/ggh/internal/theme"
+	"github.com/mattn/go-isatty"
+)
+
+const defaultGuardLabel = "production"
+
+// BypassLog returns ~/.ggh/bypass.log, where the connections made with
+// --i-know are written
+func BypassLog() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ggh", "bypass.log"), nil
+}
+
+// Guard applies the guards of the settings to the connection made with
+// args, see applyGuards. Any host could be guarded when settings.json can't
+// be parsed, so nothing is connected to then without --i-know.
+func Guard(args []string, opts Options, s settings.Settings) ([]string, Options, error) {
+	conn := ConnectionFor(args)
+	if err := s.Err(); err != nil {
+		if !opts.IKnow {
+			return args, opts, fmt.Errorf("its guards can't be checked: %w", err)
+		}
+		path, err := logBypass(conn, []string{"unchecked"}, []string{err.Error()}, time.Now())
+		if err != nil {
+			return args, opts, fmt.Errorf("--i-know must be logged: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "ggh: guards unchecked with --i-know, logged to %s\n", path)
+		return args, opts, nil
+	}
+	if len(s.Guards) == 0 {
+		return args, opts, nil
+	}
+
+	store, _ := meta.Load()
+	guards := guardsFor(s.Guards, conn, tagsOf(conn, store))
+	if len(guards) == 0 {
+		return args, opts, nil
+	}
+	return applyGuards(guards, args, conn, opts, terminalInput(), os.Stderr, time.Now())
+}
+
+// terminalInput returns the reader of the confirmations, nil when stdin
+// isn't a terminal
+var terminalInput = func() *bufio.Reader {
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		return stdin
+	}
+	return nil
+}
+
+// GuardUnrecorded is Guard for the connections ggh can't record, made by
+// exec and the tools. The guards requiring a recording refuse them.
+func GuardUnrecorded(args []string, opts Options, s settings.Settings) ([]string, error) {
+	args, guarded, err := Guard(args, opts, s)
+	if err == nil && guarded.Record && !opts.Record {
+		err = errors.New("the guard requires a recording, only ssh sessions are recorded")
+	}
+	return args, err
+}
+
+// guardsFor returns the guards matching the alias, HostName or tags of conn
+func guardsFor(guards []settings.Guard, conn Connection, tags []string) []settings.Guard {
+	names := []string{conn.Host}
+	if conn.Alias != "" {
+		names = append(names, conn.Alias)
+	}
+
+	var matched []settings.Guard
+	for _, g := range guards {
+		if slices.ContainsFunc(g.Match, func(pattern string) bool {
+			if tag, ok := strings.CutPrefix(pattern, "tag:"); ok {
+				return matchesAny(tag, tags)
+			}
+			return matchesAny(pattern, names)
+		}) {
+			matched = append(matched, g)
+		}
+	}
+	return matched
+}
+
+// tagsOf returns the tags of the host of conn. Direct connections are
+// saved without the port when it is 22, both keys are looked up.
+func tagsOf(conn Connection, store meta.Store) []string {
+	c := config.SSHConfig{Name: conn.Alias, Host: conn.Host, Port: conn.Port, User: conn.User}
+	if m := store.Get(c.MetaKey()); !m.IsZero() || conn.Alias != "" || conn.Port != "22" {
+		return m.Tags
+	}
+	c.Port = ""
+	return store.Get(c.MetaKey()).Tags
+}
+
+// applyGuards shows the banner of the guards, then refuses the connection
+// when it breaks one of their restrictions or when the user doesn't type
+// the name of the host. in is nil when stdin isn't a terminal. It returns
+// the arguments and options ssh runs with, the guards adding theirs.
+func applyGuards(guards []settings.Guard, args []string, conn Connection, opts Options, in *bufio.Reader, out io.Writer, now time.Time) ([]string, Options, error) {
+	name := conn.Alias
+	if name == "" {
+		name = conn.Host
+	}
+
+	var labels, violations []string
+	for _, g := range guards {
+		label := g.Label
+		if label == "" {
+			label = defaultGuardLabel
+		}
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+
+		if g.NoAgentForwarding && conn.ForwardAgent {
+			violations = append(violations, "agent forwarding is forbidden on "+label+" hosts")
+		}
+		if g.Hours != "" {
+			ok, err := withinHours(g.Hours, now)
+			if err != nil {
+				return args, opts, err
+			}
+			if !ok {
+				violations = append(violations, label+" hosts can only be reached "+g.Hours)
+			}
+		}
+	}
+
+	banner := fmt.Sprintf("⚠ %s: %s", strings.ToUpper(strings.Join(labels, ", ")), name)
+	fmt.Fprintln(out, theme.BannerStyle.Render(banner))
+
+	if opts.IKnow {
+		path, err := logBypass(conn, labels, violations, now)
+		if err != nil {
+			return args, opts, fmt.Errorf("--i-know must be logged: %w", err)
+		}
+		fmt.Fprintf(out, "ggh: guard bypassed with --i-know, logged to %s\n", path)
+		return args, opts, nil
+	}
+	if len(violations) > 0 {
+		return args, opts, errors.New(strings.Join(violations, ", "))
+	}
+
+	for _, g := range guards {
+		if g.NoAgentForwarding {
+			// -a wins over ForwardAgent in the ssh config
+			args = append([]string{"-a"}, args...)
+		}
+		if g.RequireRecording {
+			opts.Record = true
+		}
+	}
+
+	if in == nil {
+		return args, opts, fmt.Errorf("%s is guarded, connect from a terminal to confirm or pass --i-know", name)
+	}
+	fmt.Fprintf(out, "Type %q to connect: ", name)
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return args, opts, errors.New("no confirmation")
+	}
+	if strings.TrimSpace(line) != name {
+		return args, opts, errors.New("the confirmation doesn't match " + name)
+	}
+	return args, opts, nil
+}
+
+// withinHours reports whether now is in the window hours, HH:MM-HH:MM in
+// local time
+func withinHours(hours string, now time.Time) (bool, error) {
+	from, to, ok := strings.Cut(hours, "-")
+	start, err1 := time.Parse("15:04", strings.TrimSpace(from))
+	end, err2 := time.Parse("15:04", strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil {
+		return false, fmt.Errorf("invalid guard hours %q, expected HH:MM-HH:MM", hours)
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	first := start.Hour()*60 + start.Minute()
+	last := end.Hour()*60 + end.Minute()
+	if first <= last {
+		return minute >= first && minute < last, nil
+	}
+	// the window ends the next day
+	return minute >= first || minute < last, nil
+}
+
+// logBypass appends the connection made with --i-know to the bypass log
+// and returns the path of the log
+func logBypass(conn Connection, labels, violations []string, now time.Time) (string, error) {
+	path, err := BypassLog()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	user := os.Getenv("USER")
+	if user == "" {
+		user = os.Getenv("USERNAME")
+	}
+	broken := strings.Join(violations, "; ")
+	if broken == "" {
+		broken = "-"
+	}
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s@%s:%s\t%s\t%s\n",
+		now.Format(time.RFC3339), user, conn.Alias, conn.User, conn.Host, conn.Port,
+		strings.Join(labels, ","), broken)
+	if err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
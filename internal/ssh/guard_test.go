@@ -0,0 +1,214 @@
+package ssh
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/meta"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestGuardsFor(t *testing.T) {
+	guards := []settings.Guard{
+		{Match: []string{"prod-*"}, Label: "prod"},
+		{Match: []string{"10.1.*", "tag:env:prod"}, Label: "dc"},
+	}
+
+	tests := []struct {
+		conn Connection
+		tags []string
+		want string
+	}{
+		{Connection{Alias: "prod-db", Host: "db.internal"}, nil, "prod"},
+		{Connection{Host: "10.1.0.4"}, nil, "dc"},
+		{Connection{Alias: "stage", Host: "10.2.0.4"}, []string{"team:web", "env:prod"}, "dc"},
+		{Connection{Alias: "prod-web", Host: "10.1.0.5"}, nil, "prod,dc"},
+		{Connection{Alias: "stage", Host: "10.2.0.4"}, []string{"env:staging"}, ""},
+	}
+	for _, tt := range tests {
+		var labels []string
+		for _, g := range guardsFor(guards, tt.conn, tt.tags) {
+			labels = append(labels, g.Label)
+		}
+		if got := strings.Join(labels, ","); got != tt.want {
+			t.Errorf("guardsFor(%+v, %v) = %q, want %q", tt.conn, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestTagsOf(t *testing.T) {
+	store := meta.Store{
+		"db":               {Tags: []string{"env:prod"}},
+		"10.0.0.5root":     {Tags: []string{"env:dev"}},
+		"10.0.0.62222root": {Tags: []string{"env:qa"}},
+	}
+
+	tests := []struct {
+		conn Connection
+		want string
+	}{
+		{Connection{Alias: "db", Host: "10.0.0.7", Port: "22"}, "env:prod"},
+		{Connection{Host: "10.0.0.5", User: "root", Port: "22"}, "env:dev"},
+		{Connection{Host: "10.0.0.6", User: "root", Port: "2222"}, "env:qa"},
+		{Connection{Host: "10.0.0.6", User: "root", Port: "22"}, ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tagsOf(tt.conn, store), ","); got != tt.want {
+			t.Errorf("tagsOf(%+v) = %q, want %q", tt.conn, got, tt.want)
+		}
+	}
+}
+
+func TestWithinHours(t *testing.T) {
+	at := func(clock string) time.Time {
+		tm, _ := time.Parse("15:04", clock)
+		return tm
+	}
+
+	tests := []struct {
+		hours string
+		now   string
+		want  bool
+	}{
+		{"09:00-18:00", "09:00", true},
+		{"09:00-18:00", "17:59", true},
+		{"09:00-18:00", "18:00", false},
+		{"09:00-18:00", "03:00", false},
+		{"22:00-06:00", "23:30", true},
+		{"22:00-06:00", "05:00", true},
+		{"22:00-06:00", "12:00", false},
+	}
+	for _, tt := range tests {
+		got, err := withinHours(tt.hours, at(tt.now))
+		if err != nil || got != tt.want {
+			t.Errorf("withinHours(%q, %s) = %v, %v, want %v", tt.hours, tt.now, got, err, tt.want)
+		}
+	}
+
+	if _, err := withinHours("9-18", at("10:00")); err == nil {
+		t.Errorf("withinHours() with invalid hours should fail")
+	}
+}
+
+func TestApplyGuards(t *testing.T) {
+	noon, _ := time.Parse("15:04", "12:00")
+	conn := Connection{Alias: "prod-db", Host: "10.0.0.7", User: "postgres", Port: "22"}
+	args := []string{"prod-db"}
+
+	tests := []struct {
+		name     string
+		guard    settings.Guard
+		conn     Connection
+		input    *string
+		wantArgs string
+		record   bool
+		wantErr  string
+	}{
+		{name: "confirmed", input: ptr("prod-db\n"), wantArgs: "prod-db"},
+		{name: "wrong name", input: ptr("prod\n"), wantErr: "doesn't match"},
+		{name: "no terminal", wantErr: "connect from a terminal"},
+		{name: "restrictions", guard: settings.Guard{NoAgentForwarding: true, RequireRecording: true, Hours: "09:00-18:00"},
+			input: ptr("prod-db\n"), wantArgs: "-a prod-db", record: true},
+		{name: "agent forwarding", guard: settings.Guard{NoAgentForwarding: true},
+			conn: Connection{Alias: "prod-db", ForwardAgent: true}, input: ptr("prod-db\n"), wantErr: "agent forwarding is forbidden on production hosts"},
+		{name: "outside hours", guard: settings.Guard{Label: "payments", Hours: "22:00-06:00"},
+			input: ptr("prod-db\n"), wantErr: "payments hosts can only be reached 22:00-06:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := conn
+			if tt.conn.Alias != "" {
+				c = tt.conn
+			}
+			// no input is no terminal
+			var in *bufio.Reader
+			if tt.input != nil {
+				in = bufio.NewReader(strings.NewReader(*tt.input))
+			}
+			var out strings.Builder
+			gotArgs, opts, err := applyGuards([]settings.Guard{tt.guard}, args, c, Options{}, in, &out, noon)
+
+			if !strings.Contains(out.String(), "prod-db") {
+				t.Errorf("banner = %q, want the host name", out.String())
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyGuards() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyGuards() error = %v", err)
+			}
+			if strings.Join(gotArgs, " ") != tt.wantArgs || opts.Record != tt.record {
+				t.Errorf("applyGuards() = %q record:%v, want %q record:%v", gotArgs, opts.Record, tt.wantArgs, tt.record)
+			}
+		})
+	}
+}
+
+func TestApplyGuardsBypass(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	conn := Connection{Alias: "prod-db", Host: "10.0.0.7", User: "postgres", Port: "22", ForwardAgent: true}
+	guards := []settings.Guard{{NoAgentForwarding: true, Hours: "09:00-18:00"}}
+
+	// nothing is asked and the restrictions are lifted, the bypass is logged
+	args, _, err := applyGuards(guards, []string{"-A", "prod-db"}, conn, Options{IKnow: true}, nil, &strings.Builder{}, now)
+	if err != nil {
+		t.Fatalf("applyGuards() with --i-know error = %v", err)
+	}
+	if strings.Join(args, " ") != "-A prod-db" {
+		t.Errorf("applyGuards() with --i-know args = %q", args)
+	}
+
+	log, err := os.ReadFile(filepath.Join(home, ".ggh", "bypass.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Split(strings.TrimSuffix(string(log), "\n"), "\t")
+	if len(fields) != 6 || fields[0] != "2024-05-01T03:00:00Z" || fields[2] != "prod-db" ||
+		fields[3] != "postgres@10.0.0.7:22" || fields[4] != "production" || !strings.Contains(fields[5], "agent forwarding") {
+		t.Errorf("bypass log = %q", log)
+	}
+}
+
+func TestRunGuarded(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	fakeSSH := writeScript(t, dir, "ssh", "echo \"ssh $*\" >> "+log+"\n")
+
+	s := settings.Settings{
+		Transport: settings.Transport{Binary: fakeSSH},
+		Guards:    []settings.Guard{{Match: []string{"10.0.0.*"}}},
+	}
+
+	// the tests have no terminal to confirm on
+	if code := run([]string{"root@10.0.0.5"}, Options{}, s); code != 1 {
+		t.Errorf("run() of a guarded host = %d, want 1", code)
+	}
+	if _, err := os.Stat(log); err == nil {
+		t.Errorf("ssh ran for a guarded host without confirmation")
+	}
+
+	// any host could be guarded by settings that can't be parsed
+	unparsed := settings.Parse([]byte(`{"guards": [`))
+	if code := run([]string{"root@10.1.0.5"}, Options{}, unparsed); code != 1 {
+		t.Errorf("run() with unparsed settings = %d, want 1", code)
+	}
+
+	if code := run([]string{"root@10.0.0.5"}, Options{IKnow: true}, s); code != 0 {
+		t.Errorf("run() with --i-know = %d, want 0", code)
+	}
+	if out, _ := os.ReadFile(log); string(out) != "ssh root@10.0.0.5\n" {
+		t.Errorf("ssh ran with %q", out)
+	}
+}
+
+func ptr(s string) *string { return &s }
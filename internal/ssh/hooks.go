@@ -0,0 +1,84 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+// HookEvent names when a hook runs
+type HookEvent string
+
+const (
+	PreConnect     HookEvent = "pre_connect"
+	PostDisconnect HookEvent = "post_disconnect"
+)
+
+// hooksFor returns the commands of the hooks matching the connection for
+// the event, in the order of the settings
+func hooksFor(hooks []settings.Hook, conn Connection, event HookEvent) []string {
+	names := []string{conn.Host}
+	if conn.Alias != "" {
+		names = append(names, conn.Alias)
+	}
+
+	var commands []string
+	for _, h := range hooks {
+		if h.Match != "" && !matchesAny(h.Match, names) {
+			continue
+		}
+		command := h.PreConnect
+		if event == PostDisconnect {
+			command = h.PostDisconnect
+		}
+		if command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// hookEnv returns the variables describing the connection to the hooks,
+// exitStatus is only set after ssh exited
+func hookEnv(conn Connection, event HookEvent, exitStatus int) []string {
+	env := []string{
+		"GGH_HOOK=" + string(event),
+		"GGH_ALIAS=" + conn.Alias,
+		"GGH_HOST=" + conn.Host,
+		"GGH_USER=" + conn.User,
+		"GGH_PORT=" + conn.Port,
+		"GGH_TUNNELS=" + strings.Join(conn.Tunnels, ","),
+	}
+	if event == PostDisconnect {
+		env = append(env, "GGH_EXIT_STATUS="+strconv.Itoa(exitStatus))
+	}
+	return env
+}
+
+// runHooks runs the commands one after the other in a shell attached to the
+// terminal, stopping at the first failure
+func runHooks(commands []string, env []string) error {
+	for _, command := range commands {
+		cmd := shellCommand(command)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("hook %q: %w", command, err)
+		}
+	}
+	return nil
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
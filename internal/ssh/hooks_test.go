@@ -0,0 +1,120 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestConnectionFor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sshConfig := "Host db\n\tHostName 10.0.0.7\n\tUser postgres\n\tPort 2200\n\tIdentityFile ~/.ssh/db\n\nHost stage\n\tHostName 10.0.0.8\n\tForwardAgent yes\n"
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args string
+		want Connection
+	}{
+		{"db", Connection{Alias: "db", Host: "10.0.0.7", User: "postgres", Port: "2200", Key: "~/.ssh/db"}},
+		{"-l admin -i ~/.ssh/admin db", Connection{Alias: "db", Host: "10.0.0.7", User: "admin", Port: "2200", Key: "~/.ssh/admin"}},
+		{"root@10.0.0.5 -p 2222", Connection{Host: "10.0.0.5", User: "root", Port: "22"}},
+		// -i reads relative paths from the working directory
+		{"-i ./id_work root@10.0.0.5", Connection{Host: "10.0.0.5", User: "root", Port: "22", Key: filepath.Join(wd, "id_work")}},
+		{"-p2222 -L 8080:localhost:80 -D1080 root@10.0.0.5", Connection{Host: "10.0.0.5", User: "root", Port: "2222", Tunnels: []string{"-L 8080:localhost:80", "-D 1080"}}},
+		{"-At db", Connection{Alias: "db", Host: "10.0.0.7", User: "postgres", Port: "2200", Key: "~/.ssh/db", ForwardAgent: true}},
+		{"-o ForwardAgent=yes root@10.0.0.5", Connection{Host: "10.0.0.5", User: "root", Port: "22", ForwardAgent: true}},
+		{"-A -o ForwardAgent=no root@10.0.0.5", Connection{Host: "10.0.0.5", User: "root", Port: "22"}},
+		{"stage", Connection{Alias: "stage", Host: "10.0.0.8", Port: "22", ForwardAgent: true}},
+		{"-a stage", Connection{Alias: "stage", Host: "10.0.0.8", Port: "22"}},
+	}
+
+	for _, tt := range tests {
+		got := ConnectionFor(strings.Fields(tt.args))
+		if got.Alias != tt.want.Alias || got.Host != tt.want.Host || got.User != tt.want.User ||
+			got.Port != tt.want.Port || got.Key != tt.want.Key || got.ForwardAgent != tt.want.ForwardAgent || strings.Join(got.Tunnels, ",") != strings.Join(tt.want.Tunnels, ",") {
+			t.Errorf("ConnectionFor(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+// writeScript writes an executable shell script into dir
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+
+	fakeSSH := writeScript(t, dir, "ssh", "echo \"ssh $*\" >> "+log+"\nexit 5\n")
+	record := writeScript(t, dir, "record", "echo \"$GGH_HOOK $GGH_HOST $GGH_USER $GGH_PORT $GGH_TUNNELS $GGH_EXIT_STATUS\" >> "+log+"\n")
+
+	s := settings.Settings{
+		Transport: settings.Transport{Binary: fakeSSH},
+		Hooks: []settings.Hook{
+			{PreConnect: record, PostDisconnect: record},
+			{Match: "10.0.0.*", PreConnect: "echo lan >> " + log},
+			{Match: "prod-*", PreConnect: "echo prod >> " + log},
+		},
+	}
+
+	code := run([]string{"-L", "8080:localhost:80", "deploy@10.0.0.5", "-p", "2222"}, Options{}, s)
+	if code != 5 {
+		t.Errorf("run() = %d, want the exit code of ssh 5", code)
+	}
+
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pre_connect 10.0.0.5 deploy 22 -L 8080:localhost:80 \n" +
+		"lan\n" +
+		"ssh -L 8080:localhost:80 deploy@10.0.0.5 -p 2222\n" +
+		"post_disconnect 10.0.0.5 deploy 22 -L 8080:localhost:80 5\n"
+	if string(got) != want {
+		t.Errorf("hooks ran as\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunFailingPreHook(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+
+	fakeSSH := writeScript(t, dir, "ssh", "echo ssh >> "+log+"\n")
+	s := settings.Settings{
+		Transport: settings.Transport{Binary: fakeSSH},
+		Hooks: []settings.Hook{
+			{PreConnect: "exit 1", PostDisconnect: "echo post >> " + log},
+			{PreConnect: "echo second >> " + log},
+		},
+	}
+
+	if code := run([]string{"root@10.0.0.5"}, Options{}, s); code == 0 {
+		t.Errorf("run() = 0 after a failing pre-connect hook")
+	}
+	if _, err := os.Stat(log); err == nil {
+		content, _ := os.ReadFile(log)
+		t.Errorf("nothing should run after a failing pre-connect hook, got %q", content)
+	}
+}
@@ -0,0 +1,231 @@
+package ssh
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/output"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+const defaultPersist = "10m"
+
+// MuxDir returns ~/.ggh/cm, where the master connection sockets live
+func MuxDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ggh", "cm"), nil
+}
+
+// muxArgs returns the ssh options sharing the connection to conn, when the
+// multiplex settings ask for it
+func muxArgs(conn Connection, s settings.Multiplex) []string {
+	names := []string{conn.Host}
+	if conn.Alias != "" {
+		names = append(names, conn.Alias)
+	}
+
+	matched := false
+	for _, pattern := range s.Hosts {
+		if matchesAny(pattern, names) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return nil
+	}
+
+	dir, err := MuxDir()
+	if err != nil || os.MkdirAll(dir, 0700) != nil {
+		return nil
+	}
+
+	persist := s.Persist
+	if persist == "" {
+		persist = defaultPersist
+	}
+	return []string{
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + filepath.Join(dir, socketPrefix(dir, conn)+"%C"),
+		"-o", "ControlPersist=" + persist,
+	}
+}
+
+// socketPrefix returns the start of the socket names of the connections to
+// conn and writes the host they reach next to them, in a .host file. ssh
+// completes the names with %C, a hash of what it connects to: user@host:port
+// names don't fit the length of a socket path with long user or host names.
+func socketPrefix(dir string, conn Connection) string {
+	port := conn.Port
+	if port == "" {
+		port = "22"
+	}
+	host := conn.Host + ":" + port
+	if conn.User != "" {
+		host = conn.User + "@" + host
+	}
+
+	sum := sha256.Sum256([]byte(host))
+	key := hex.EncodeToString(sum[:4])
+	// without it the master still works, it is only listed without its host
+	_ = os.WriteFile(filepath.Join(dir, key+hostSuffix), []byte(host+"\n"), 0600)
+	return key + "-"
+}
+
+// hostSuffix ends the files naming the host of the sockets
+const hostSuffix = ".host"
+
+// Master is a master connection socket in MuxDir
+type Master struct {
+	Path  string
+	User  string
+	Host  string
+	Port  string
+	Since time.Time
+	// Live is false for sockets left behind by a master that is gone
+	Live bool
+}
+
+// Masters returns the master connections, the oldest first
+func Masters() ([]Master, error) {
+	dir, err := MuxDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var masters []Master
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSocket == 0 {
+			continue
+		}
+		m := socketHost(dir, entry.Name())
+		m.Path = filepath.Join(dir, entry.Name())
+		if info, err := entry.Info(); err == nil {
+			m.Since = info.ModTime()
+		}
+		if conn, err := net.DialTimeout("unix", m.Path, time.Second); err == nil {
+			conn.Close()
+			m.Live = true
+		}
+		masters = append(masters, m)
+	}
+
+	sort.SliceStable(masters, func(i, j int) bool { return masters[i].Since.Before(masters[j].Since) })
+	return masters, nil
+}
+
+// socketHost reads the user, host and port of the socket name from the
+// user@host:port written by socketPrefix
+func socketHost(dir, name string) Master {
+	var m Master
+	key, _, ok := strings.Cut(name, "-")
+	if !ok {
+		return m
+	}
+	content, err := os.ReadFile(filepath.Join(dir, key+hostSuffix))
+	if err != nil {
+		return m
+	}
+
+	rest := strings.TrimSpace(string(content))
+	if at := strings.Index(rest, "@"); at >= 0 {
+		m.User, rest = rest[:at], rest[at+1:]
+	}
+	if colon := strings.LastIndex(rest, ":"); colon >= 0 {
+		rest, m.Port = rest[:colon], rest[colon+1:]
+	}
+	m.Host = rest
+	return m
+}
+
+// StopMasters asks the master connections to host to exit through the
+// transport t. host is an alias, a HostName or user@host.
+func StopMasters(host string, t settings.Transport) ([]Master, error) {
+	masters, err := Masters()
+	if err != nil {
+		return nil, err
+	}
+
+	user, name := "", host
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		user, name = host[:at], host[at+1:]
+	}
+	names := []string{name}
+	if c, err := config.GetConfig(name); err == nil && c.Host != "" {
+		names = append(names, c.Host)
+	}
+
+	// the names typed may be patterns, the hosts of the sockets never are
+	matches := func(host string) bool {
+		return slices.ContainsFunc(names, func(name string) bool {
+			return matchesAny(name, []string{host})
+		})
+	}
+
+	var stopped []Master
+	for _, m := range masters {
+		if (user != "" && m.User != user) || !matches(m.Host) {
+			continue
+		}
+		if !m.Live {
+			// nothing to ask, the socket is a leftover
+			_ = os.Remove(m.Path)
+			stopped = append(stopped, m)
+			continue
+		}
+
+		args := []string{"-O", "exit", "-o", "ControlPath=" + m.Path, m.Host}
+		cmd := TransportFor(t, args).Command(context.Background(), args)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return stopped, fmt.Errorf("stopping the master connection to %s: %w", m.Host, err)
+		}
+		stopped = append(stopped, m)
+	}
+
+	if len(stopped) == 0 {
+		return nil, fmt.Errorf("no master connection to %s", host)
+	}
+	return stopped, nil
+}
+
+// MasterKeys are the fields of a master connection in machine readable output
+var MasterKeys = []string{"host", "user", "port", "since", "age_seconds", "live", "path"}
+
+// MasterRecords converts master connections to output records
+func MasterRecords(masters []Master) []output.Record {
+	records := make([]output.Record, 0, len(masters))
+	for _, m := range masters {
+		records = append(records, output.Record{
+			{Key: "host", Value: m.Host},
+			{Key: "user", Value: m.User},
+			{Key: "port", Value: m.Port},
+			{Key: "since", Value: m.Since.Format(time.RFC3339)},
+			{Key: "age_seconds", Value: int64(time.Since(m.Since).Seconds())},
+			{Key: "live", Value: m.Live},
+			{Key: "path", Value: m.Path},
+		})
+	}
+	return records
+}
@@ -0,0 +1,218 @@
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestMuxArgs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	s := settings.Multiplex{Hosts: []string{"bastion", "*.prod.example.com"}}
+
+	long := strings.Repeat("a", 64)
+	tests := []struct {
+		conn Connection
+		// host is what the .host file next to the socket says, none when
+		// the connection isn't shared
+		host string
+	}{
+		{Connection{Alias: "bastion", Host: "10.0.0.1", User: "deploy"}, "deploy@10.0.0.1:22"},
+		{Connection{Host: "db.prod.example.com", Port: "2222"}, "db.prod.example.com:2222"},
+		{Connection{Host: long + ".prod.example.com", User: long, Port: "22"}, long + "@" + long + ".prod.example.com:22"},
+		{Connection{Alias: "stage", Host: "10.0.0.2"}, ""},
+	}
+	dir := filepath.Join(home, ".ggh", "cm")
+	for _, tt := range tests {
+		got := muxArgs(tt.conn, s)
+		if tt.host == "" {
+			if got != nil {
+				t.Errorf("muxArgs(%+v) = %q, want none", tt.conn, got)
+			}
+			continue
+		}
+
+		if len(got) != 6 || got[1] != "ControlMaster=auto" || got[5] != "ControlPersist=10m" {
+			t.Fatalf("muxArgs(%+v) = %q", tt.conn, got)
+		}
+		path := strings.TrimPrefix(got[3], "ControlPath=")
+		key, ok := strings.CutSuffix(filepath.Base(path), "-%C")
+		if filepath.Dir(path) != dir || !ok {
+			t.Errorf("muxArgs(%+v) ControlPath = %q, want %s/KEY-%%C", tt.conn, path, dir)
+		}
+		// ssh expands %C to 40 characters, whatever the length of the names
+		if len(path)-len("%C")+40 >= 104 {
+			t.Errorf("muxArgs(%+v) ControlPath %q is too long for a socket", tt.conn, path)
+		}
+		if content, _ := os.ReadFile(filepath.Join(dir, key+".host")); string(content) != tt.host+"\n" {
+			t.Errorf("muxArgs(%+v) wrote host %q, want %q", tt.conn, content, tt.host)
+		}
+	}
+
+	s.Persist = "1h"
+	if got := muxArgs(tests[0].conn, s); got[len(got)-1] != "ControlPersist=1h" {
+		t.Errorf("muxArgs() with persist 1h = %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(home, ".ggh", "cm")); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("socket directory not created private: %v %v", info, err)
+	}
+}
+
+func TestRunMultiplexed(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	fakeSSH := writeScript(t, dir, "ssh", "echo \"$*\" >> "+log+"\n")
+
+	s := settings.Settings{
+		Transport: settings.Transport{Binary: fakeSSH},
+		Multiplex: settings.Multiplex{Hosts: []string{"10.0.0.*"}, Persist: "30s"},
+	}
+	if code := run([]string{"deploy@10.0.0.5"}, Options{}, s); code != 0 {
+		t.Fatalf("run() = %d", code)
+	}
+	if code := run([]string{"deploy@192.168.0.5"}, Options{}, s); code != 0 {
+		t.Fatalf("run() = %d", code)
+	}
+
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := filepath.Join(home, ".ggh", "cm")
+	prefix := socketPrefix(cm, Connection{User: "deploy", Host: "10.0.0.5"})
+	want := "-o ControlMaster=auto -o ControlPath=" + filepath.Join(cm, prefix+"%C") + " -o ControlPersist=30s deploy@10.0.0.5\n" +
+		"deploy@192.168.0.5\n"
+	if string(got) != want {
+		t.Errorf("ssh was run with\n%s\nwant\n%s", got, want)
+	}
+}
+
+// listen creates a master socket to conn in the socket directory, closed at
+// the end of the test unless stale, in which case only the file is left
+// behind. The hash ssh would add is made up.
+func listen(t *testing.T, conn Connection, stale bool) string {
+	t.Helper()
+	dir, err := MuxDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, socketPrefix(dir, conn)+"5d41402abc4b2a76b9719d911017c592")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stale {
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+	} else {
+		t.Cleanup(func() { l.Close() })
+	}
+	return path
+}
+
+func TestMasters(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if masters, err := Masters(); err != nil || len(masters) != 0 {
+		t.Fatalf("Masters() without a socket directory = %v, %v", masters, err)
+	}
+
+	listen(t, Connection{User: "deploy", Host: "10.0.0.1"}, false)
+	listen(t, Connection{User: "root", Host: "fe80::1", Port: "2222"}, true)
+	dir, _ := MuxDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	masters, err := Masters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the .host files aren't sockets
+	if len(masters) != 2 {
+		t.Fatalf("Masters() = %+v, want the two sockets", masters)
+	}
+	found := map[string]Master{}
+	for _, m := range masters {
+		found[m.User+" "+m.Host+" "+m.Port] = m
+	}
+	if m, ok := found["deploy 10.0.0.1 22"]; !ok || !m.Live {
+		t.Errorf("live master not listed as live: %+v", masters)
+	}
+	if m, ok := found["root fe80::1 2222"]; !ok || m.Live {
+		t.Errorf("stale master not listed as stale: %+v", masters)
+	}
+}
+
+func TestStopMasters(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sshConfig := "Host bastion\n\tHostName 10.0.0.1\n\tUser deploy\n"
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the fake ssh removes the socket like an exiting master does
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	fakeSSH := writeScript(t, dir, "ssh", "echo \"$*\" >> "+log+"\n"+
+		"for a; do case \"$a\" in ControlPath=*) rm -f \"${a#ControlPath=}\";; esac; done\n")
+	transport := settings.Transport{Binary: fakeSSH}
+
+	live := listen(t, Connection{User: "deploy", Host: "10.0.0.1"}, false)
+	stale := listen(t, Connection{User: "root", Host: "10.0.0.1"}, true)
+	other := listen(t, Connection{User: "deploy", Host: "10.0.0.2"}, false)
+	wildcard := listen(t, Connection{User: "deploy", Host: "*"}, false)
+
+	if _, err := StopMasters("nowhere", transport); err == nil {
+		t.Error("StopMasters(nowhere) succeeded, want an error")
+	}
+
+	stopped, err := StopMasters("bastion", transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stopped) != 2 {
+		t.Errorf("StopMasters(bastion) stopped %+v, want both sockets of 10.0.0.1", stopped)
+	}
+
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-O exit -o ControlPath=" + live + " 10.0.0.1\n"; string(got) != want {
+		t.Errorf("ssh was run with %q, want %q, stale sockets are only removed", got, want)
+	}
+	for _, path := range []string{live, stale} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", path)
+		}
+	}
+	for _, path := range []string{other, wildcard} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("the master connection to another host was stopped: %v", err)
+		}
+	}
+
+	// the name typed is a pattern, not the host of the socket
+	os.Remove(log)
+	if stopped, err := StopMasters("10.0.0.*", transport); err != nil || len(stopped) != 1 || stopped[0].Path != other {
+		t.Errorf("StopMasters(10.0.0.*) = %+v, %v, want the master to 10.0.0.2", stopped, err)
+	}
+
+	if _, err := StopMasters("root@10.0.0.2", transport); err == nil {
+		t.Error("StopMasters(root@10.0.0.2) succeeded, want an error as only deploy is connected")
+	}
+}
@@ -0,0 +1,38 @@
+package ssh
+
+import (
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+// JumpHost returns how ssh -J names c: the alias, or [user@]host[:port] for
+// a direct connection. ssh -J can't pass a key, the ones of direct jump hosts
+// have to come from the agent or the ssh config.
+func JumpHost(c config.SSHConfig) string {
+	if c.Name != "" && !c.IsDirectSSH() {
+		return c.Name
+	}
+	spec := c.Host
+	if c.User != "" {
+		spec = c.User + "@" + spec
+	}
+	if c.Port != "" && c.Port != "22" {
+		spec += ":" + c.Port
+	}
+	return spec
+}
+
+// JumpChain joins the jump hosts in the order they are crossed
+func JumpChain(hops []config.SSHConfig) string {
+	specs := make([]string, 0, len(hops))
+	for _, hop := range hops {
+		specs = append(specs, JumpHost(hop))
+	}
+	return strings.Join(specs, ",")
+}
+
+// Args returns the ssh arguments connecting to e through its jump hosts
+func (e Endpoint) Args() []string {
+	return append(e.sshOptions("-p"), e.Destination())
+}
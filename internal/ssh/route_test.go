@@ -0,0 +1,45 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+)
+
+func TestJumpHost(t *testing.T) {
+	tests := []struct {
+		c    config.SSHConfig
+		want string
+	}{
+		{config.SSHConfig{Name: "bastion", Host: "10.0.0.1", User: "deploy", Port: "2200"}, "bastion"},
+		{config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.2", User: "deploy", Port: "2200"}, "deploy@10.0.0.2:2200"},
+		{config.SSHConfig{Host: "10.0.0.3", Port: "22"}, "10.0.0.3"},
+	}
+	for _, tt := range tests {
+		if got := JumpHost(tt.c); got != tt.want {
+			t.Errorf("JumpHost(%+v) = %q, want %q", tt.c, got, tt.want)
+		}
+	}
+
+	hops := []config.SSHConfig{tests[0].c, tests[1].c, tests[2].c}
+	if got, want := JumpChain(hops), "bastion,deploy@10.0.0.2:2200,10.0.0.3"; got != want {
+		t.Errorf("JumpChain() = %q, want %q", got, want)
+	}
+}
+
+func TestEndpointArgs(t *testing.T) {
+	tests := []struct {
+		e    Endpoint
+		want string
+	}{
+		{Endpoint{SSHConfig: config.SSHConfig{Name: "db", Host: "10.1.0.7", Port: "2222"}, Jump: "bastion"}, "-J bastion db"},
+		{Endpoint{SSHConfig: config.SSHConfig{Host: "10.1.0.7", User: "admin", Key: "~/.ssh/id"}, Jump: "a,b"}, "-i ~/.ssh/id -J a,b admin@10.1.0.7"},
+		{Endpoint{SSHConfig: config.SSHConfig{Host: "10.1.0.7"}}, "root@10.1.0.7"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.e.Args(), " "); got != tt.want {
+			t.Errorf("%+v.Args() = %q, want %q", tt.e, got, tt.want)
+		}
+	}
+}
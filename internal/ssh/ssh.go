@@ -1,14 +1,25 @@
 package ssh
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/MrLonely14/ggh/internal/agent"
 	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/record"
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/mattn/go-isatty"
 	"os"
 	"os/exec"
 	"slices"
 	"strings"
 )
 
+// stdin is read by all the prompts before ssh runs, a reader each would
+// buffer the answers meant for the next one
+var stdin = bufio.NewReader(os.Stdin)
+
 func GenerateCommandArgs(c config.SSHConfig) []string {
 	key, port := "", ""
 	user := "root"
@@ -27,12 +38,122 @@ func GenerateCommandArgs(c config.SSHConfig) []string {
 	return strings.Split(fmt.Sprintf("%s@%s %s %s", user, c.Host, key, port), " ")
 }
 
-func Run(args []string) {
+// Options change how Run connects
+type Options struct {
+	// Record records the session, as the recording settings do per host
+	Record bool
+	// IKnow bypasses the guards of the host, the bypass is logged
+	IKnow bool
+}
+
+// Run connects with args, running the hooks around ssh, and returns the
+// exit code of ssh. A failing pre-connect hook cancels the connection.
+func Run(args []string) int {
+	return RunWith(args, Options{})
+}
+
+// RunWith is Run with options
+func RunWith(args []string, opts Options) int {
+	return run(args, opts, settings.Get())
+}
+
+// Recorded reports whether the connection to conn is recorded
+func Recorded(conn Connection, opts Options, s settings.Settings) bool {
+	if opts.Record {
+		return true
+	}
+	names := []string{conn.Host}
+	if conn.Alias != "" {
+		names = append(names, conn.Alias)
+	}
+	for _, pattern := range s.Recording.Hosts {
+		if matchesAny(pattern, names) {
+			return true
+		}
+	}
+	return false
+}
+
+func run(args []string, opts Options, s settings.Settings) int {
 	args = slices.DeleteFunc(args, func(s string) bool { return s == "" })
 
-	cmd := exec.Command("ssh", args...)
+	var err error
+	args, opts, err = Guard(args, opts, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: not connecting, %v\n", err)
+		return 1
+	}
+
+	conn := ConnectionFor(args)
+	if len(s.Hooks) > 0 {
+		pre := hooksFor(s.Hooks, conn, PreConnect)
+		if err := runHooks(pre, hookEnv(conn, PreConnect, 0)); err != nil {
+			fmt.Fprintf(os.Stderr, "ggh: not connecting, pre-connect %v\n", err)
+			return 1
+		}
+	}
+
+	// the agent is checked after the hooks, they may load the key themselves
+	if s.Agent.Enabled && isatty.IsTerminal(os.Stdin.Fd()) {
+		if err := agent.Ensure(conn.Key, s.Agent, stdin, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "ggh: ssh-add failed, connecting anyway: %v\n", err)
+		}
+	}
+
+	transport := TransportFor(s.Transport, args)
+	cmd := transport.Command(context.Background(), append(muxArgs(conn, s.Multiplex), args...))
+
+	var code int
+	if Recorded(conn, opts, s) {
+		code = runRecorded(cmd, conn)
+	} else {
+		code = runAttached(cmd)
+	}
+
+	if len(s.Hooks) > 0 {
+		post := hooksFor(s.Hooks, conn, PostDisconnect)
+		if err := runHooks(post, hookEnv(conn, PostDisconnect, code)); err != nil {
+			fmt.Fprintf(os.Stderr, "ggh: post-disconnect %v\n", err)
+		}
+	}
+	return code
+}
+
+// runAttached runs cmd on the user's terminal
+func runAttached(cmd *exec.Cmd) int {
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	_ = cmd.Run()
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		return 255
+	}
+	return 0
+}
+
+// runRecorded runs cmd under a terminal ggh records. Sessions that must be
+// recorded are not started when recording isn't possible.
+func runRecorded(cmd *exec.Cmd, conn Connection) int {
+	name := conn.Alias
+	if name == "" {
+		name = conn.Host
+	}
+
+	fmt.Fprintln(os.Stderr, "ggh: this session is recorded")
+	code, path, err := record.Session(cmd, name)
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "ggh: session recorded to %s\n", path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggh: %v\n", err)
+		if code == 0 {
+			code = 255
+		}
+	}
+	return code
 }
@@ -0,0 +1,265 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+// Tool is a program ggh starts against a picked host instead of ssh
+type Tool string
+
+const (
+	Mosh  Tool = "mosh"
+	SFTP  Tool = "sftp"
+	SCP   Tool = "scp"
+	Rsync Tool = "rsync"
+)
+
+// Endpoint is the host a tool connects to, optionally through jump hosts
+type Endpoint struct {
+	config.SSHConfig
+	// Jump is a comma separated list of jump hosts, as given to ssh -J
+	Jump string
+	// Options are more ssh options, like the -a of a guard
+	Options []string
+}
+
+// isAlias reports whether ssh resolves the endpoint from the ssh config,
+// which then also provides its port and key
+func (e Endpoint) isAlias() bool {
+	return e.Name != "" && !e.IsDirectSSH()
+}
+
+// Destination returns the alias, or user@host for a direct connection with
+// the same default user as GenerateCommandArgs
+func (e Endpoint) Destination() string {
+	if e.isAlias() {
+		return e.Name
+	}
+	user := "root"
+	if e.User != "" {
+		user = e.User
+	}
+	return user + "@" + e.Host
+}
+
+// sshOptions returns the ssh flags for the port, key and jump hosts that
+// aren't already in the ssh config. portFlag is -p for ssh, -P for scp and sftp.
+func (e Endpoint) sshOptions(portFlag string) []string {
+	var opts []string
+	if !e.isAlias() {
+		if e.Port != "" {
+			opts = append(opts, portFlag, e.Port)
+		}
+		if e.Key != "" {
+			opts = append(opts, "-i", e.Key)
+		}
+	}
+	if e.Jump != "" {
+		opts = append(opts, "-J", e.Jump)
+	}
+	for _, o := range e.Options {
+		if option, ok := scpFlags[o]; ok && portFlag == "-P" {
+			opts = append(opts, option...)
+			continue
+		}
+		opts = append(opts, o)
+	}
+	return opts
+}
+
+// scpFlags are the ssh flags of Options as the -o options scp and sftp pass
+// on to ssh, their own flags differ
+var scpFlags = map[string][]string{"-a": {"-o", "ForwardAgent=no"}}
+
+// ToolArgs returns the arguments that make tool connect to e. For scp and
+// rsync args are the tool's own arguments, the ones starting with ':' are
+// paths on e. For mosh and sftp args go before the destination. The tools
+// run ssh as the transport settings say, sharing the connection when the
+// multiplex settings ask for it.
+func ToolArgs(tool Tool, e Endpoint, args []string, s settings.Settings) []string {
+	transport, mux := e.transport(s)
+	// the ssh command line of the tools that take one
+	sshCommand := append(append(append([]string{transport.Binary}, transport.Args...), mux...), e.sshOptions("-p")...)
+
+	var out []string
+	switch tool {
+	case Mosh:
+		// mosh's own --port is the UDP port, ssh gets the options instead
+		if len(sshCommand) > 1 || transport.Binary != defaultBinary {
+			out = append(out, "--ssh="+shellJoin(sshCommand))
+		}
+		out = append(out, args...)
+		out = append(out, e.Destination())
+	case SFTP, SCP:
+		// -S takes a program without arguments, the transport arguments
+		// are ssh options that scp and sftp take too
+		if transport.Binary != defaultBinary {
+			out = append(out, "-S", transport.Binary)
+		}
+		out = append(out, transport.Args...)
+		out = append(out, mux...)
+		out = append(out, e.sshOptions("-P")...)
+		if tool == SFTP {
+			out = append(out, args...)
+			out = append(out, e.Destination())
+		} else {
+			out = append(out, remotePaths(e, args)...)
+		}
+	case Rsync:
+		if len(sshCommand) > 1 || transport.Binary != defaultBinary {
+			out = append(out, "-e", shellJoin(sshCommand))
+		}
+		out = append(out, remotePaths(e, args)...)
+	}
+	return out
+}
+
+// hasHost reports whether e names a host, scp and rsync can do without
+func (e Endpoint) hasHost() bool {
+	return e.Host != "" || e.Name != ""
+}
+
+// transport returns the transport of the connection to e and the options
+// sharing it. Without a host it is the default transport of the settings.
+func (e Endpoint) transport(s settings.Settings) (Transport, []string) {
+	if !e.hasHost() {
+		return TransportFor(s.Transport, nil), nil
+	}
+	args := e.Args()
+	return TransportFor(s.Transport, args), muxArgs(ConnectionFor(args), s.Multiplex)
+}
+
+// HasRemotePath reports whether one of args is a ':path' that needs a host
+func HasRemotePath(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, ":") {
+			return true
+		}
+	}
+	return false
+}
+
+// remotePaths prefixes the ':path' arguments with the destination of e
+func remotePaths(e Endpoint, args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if strings.HasPrefix(arg, ":") {
+			arg = e.Destination() + arg
+		}
+		out[i] = arg
+	}
+	return out
+}
+
+// shellJoin joins words into a command line for tools that split their
+// ssh command again, quoting the words that need it
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		if w == "" || strings.ContainsAny(w, " \t\n'\"\\$`") {
+			w = "'" + strings.ReplaceAll(w, "'", `'\''`) + "'"
+		}
+		quoted[i] = w
+	}
+	return strings.Join(quoted, " ")
+}
+
+// CheckTool returns the path of the tool's binary
+func CheckTool(tool Tool) (string, error) {
+	path, err := exec.LookPath(string(tool))
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed", tool)
+	}
+	return path, nil
+}
+
+// RunTool connects tool to e, see ToolArgs, attached to the terminal and
+// returns its exit code. The guards of e and of the hosts named in the
+// arguments of scp and rsync are applied first, then the hooks run around
+// the tool as they do around ssh.
+func RunTool(tool Tool, e Endpoint, args []string, opts Options) (int, error) {
+	return runTool(tool, e, args, opts, settings.Get())
+}
+
+func runTool(tool Tool, e Endpoint, args []string, opts Options, s settings.Settings) (int, error) {
+	path, err := CheckTool(tool)
+	if err != nil {
+		return 1, err
+	}
+
+	var targets [][]string
+	if e.hasHost() {
+		targets = append(targets, e.Args())
+	}
+	if tool == SCP || tool == Rsync {
+		for _, host := range namedHosts(args) {
+			targets = append(targets, []string{host})
+		}
+	}
+	var conns []Connection
+	for _, target := range targets {
+		guarded, err := GuardUnrecorded(target, opts, s)
+		if err != nil {
+			return 1, fmt.Errorf("not connecting, %w", err)
+		}
+		// the guards put their ssh options before the target, the tool's
+		// ssh gets them for every host
+		for _, o := range guarded[:len(guarded)-len(target)] {
+			if !slices.Contains(e.Options, o) {
+				e.Options = append(e.Options, o)
+			}
+		}
+		conns = append(conns, ConnectionFor(target))
+	}
+	for _, conn := range conns {
+		pre := hooksFor(s.Hooks, conn, PreConnect)
+		if err := runHooks(pre, hookEnv(conn, PreConnect, 0)); err != nil {
+			return 1, fmt.Errorf("not connecting, pre-connect %w", err)
+		}
+	}
+
+	transport, _ := e.transport(s)
+	args = ToolArgs(tool, e, args, s)
+	fmt.Fprintf(os.Stderr, "%s %s\n", tool, strings.Join(args, " "))
+
+	cmd := exec.Command(path, args...)
+	if len(transport.Env) > 0 {
+		cmd.Env = append(os.Environ(), transport.Env...)
+	}
+	code := runAttached(cmd)
+
+	for _, conn := range conns {
+		post := hooksFor(s.Hooks, conn, PostDisconnect)
+		if err := runHooks(post, hookEnv(conn, PostDisconnect, code)); err != nil {
+			fmt.Fprintf(os.Stderr, "ggh: post-disconnect %v\n", err)
+		}
+	}
+	return code, nil
+}
+
+// namedHosts returns the hosts of the [user@]host:path arguments of scp and
+// rsync, the ':path' ones are on the endpoint instead
+func namedHosts(args []string) []string {
+	var hosts []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-o" || arg == "-e" {
+			// their values are ssh options and commands
+			i++
+			continue
+		}
+		host, _, ok := strings.Cut(arg, ":")
+		if !ok || host == "" || strings.HasPrefix(arg, "-") || strings.Contains(host, "/") {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
@@ -0,0 +1,176 @@
+package ssh
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestToolArgs(t *testing.T) {
+	direct := Endpoint{SSHConfig: config.SSHConfig{Host: "10.0.0.5", User: "deploy", Port: "2222", Key: "/keys/my key"}}
+	alias := Endpoint{SSHConfig: config.SSHConfig{Name: "stage", Host: "10.0.0.1", Port: "2200", Key: "/keys/stage"}}
+	jumped := Endpoint{SSHConfig: config.SSHConfig{Name: config.DirectSSH, Host: "10.0.0.6"}, Jump: "bastion,inner"}
+	noAgent := Endpoint{SSHConfig: config.SSHConfig{Name: "prod"}, Options: []string{"-a"}}
+
+	tests := []struct {
+		name     string
+		tool     Tool
+		endpoint Endpoint
+		args     []string
+		want     []string
+	}{
+		{"mosh direct", Mosh, direct, nil, []string{"--ssh=ssh -p 2222 -i '/keys/my key'", "deploy@10.0.0.5"}},
+		{"mosh alias", Mosh, alias, nil, []string{"stage"}},
+		{"mosh jump", Mosh, jumped, nil, []string{"--ssh=ssh -J bastion,inner", "root@10.0.0.6"}},
+		{"sftp direct", SFTP, direct, nil, []string{"-P", "2222", "-i", "/keys/my key", "deploy@10.0.0.5"}},
+		{"sftp alias", SFTP, alias, nil, []string{"stage"}},
+		{"sftp jump", SFTP, jumped, nil, []string{"-J", "bastion,inner", "root@10.0.0.6"}},
+		{"scp upload", SCP, direct, []string{"-r", "dir", ":/srv/"}, []string{"-P", "2222", "-i", "/keys/my key", "-r", "dir", "deploy@10.0.0.5:/srv/"}},
+		{"scp download", SCP, alias, []string{":/var/log/syslog", "."}, []string{"stage:/var/log/syslog", "."}},
+		{"scp named hosts", SCP, Endpoint{}, []string{"a:/x", "b:/y"}, []string{"a:/x", "b:/y"}},
+		{"rsync direct", Rsync, direct, []string{"-avz", "dir/", ":/srv/"}, []string{"-e", "ssh -p 2222 -i '/keys/my key'", "-avz", "dir/", "deploy@10.0.0.5:/srv/"}},
+		{"rsync alias", Rsync, alias, []string{"-avz", ":/srv/", "."}, []string{"-avz", "stage:/srv/", "."}},
+		{"rsync jump", Rsync, jumped, []string{":/srv/", "."}, []string{"-e", "ssh -J bastion,inner", "root@10.0.0.6:/srv/", "."}},
+		{"mosh options", Mosh, noAgent, nil, []string{"--ssh=ssh -a", "prod"}},
+		{"sftp options", SFTP, noAgent, nil, []string{"-o", "ForwardAgent=no", "prod"}},
+		{"scp options", SCP, noAgent, []string{"dir", ":/srv"}, []string{"-o", "ForwardAgent=no", "dir", "prod:/srv"}},
+		{"rsync options", Rsync, noAgent, []string{"dir", ":/srv"}, []string{"-e", "ssh -a", "dir", "prod:/srv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToolArgs(tt.tool, tt.endpoint, tt.args, settings.Settings{})
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ToolArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasRemotePath(t *testing.T) {
+	if !HasRemotePath([]string{"-r", "dir", ":/srv"}) {
+		t.Errorf("HasRemotePath() missed :/srv")
+	}
+	if HasRemotePath([]string{"dir", "host:/srv"}) {
+		t.Errorf("HasRemotePath() matched host:/srv")
+	}
+}
+
+// TestRunTool runs every tool as a stub script that records its arguments
+func TestRunTool(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	endpoint := Endpoint{SSHConfig: config.SSHConfig{Host: "10.0.0.5", User: "deploy", Port: "2222"}}
+	for _, tool := range []Tool{Mosh, SFTP, SCP, Rsync} {
+		out := filepath.Join(dir, string(tool)+".args")
+		script := "#!/bin/sh\nfor a in \"$@\"; do echo \"$a\"; done > " + out + "\nexit 3\n"
+		if err := os.WriteFile(filepath.Join(dir, string(tool)), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		var toolArgs []string
+		if tool == SCP || tool == Rsync {
+			toolArgs = []string{":/tmp/file", "."}
+		}
+		args := ToolArgs(tool, endpoint, toolArgs, settings.Settings{})
+		code, err := runTool(tool, endpoint, toolArgs, Options{}, settings.Settings{})
+		if err != nil || code != 3 {
+			t.Fatalf("RunTool(%s) = %d, %v, want 3", tool, code, err)
+		}
+
+		got, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(got)) != strings.Join(args, "\n") {
+			t.Errorf("%s got %q, want %q", tool, got, args)
+		}
+	}
+
+	if _, err := runTool("missing-tool", endpoint, nil, Options{}, settings.Settings{}); err == nil {
+		t.Errorf("RunTool() of a missing binary should fail")
+	}
+}
+
+func TestRunToolGuarded(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	log := filepath.Join(dir, "log")
+	writeScript(t, dir, "scp", "echo \"scp $*\" >> "+log+"\n")
+
+	guarded := settings.Settings{Guards: []settings.Guard{{Match: []string{"10.0.0.*"}}}}
+	prod := Endpoint{SSHConfig: config.SSHConfig{Host: "10.0.0.5", User: "deploy"}}
+	stage := Endpoint{SSHConfig: config.SSHConfig{Host: "10.1.0.5", User: "deploy"}}
+
+	tests := []struct {
+		name     string
+		endpoint Endpoint
+		args     []string
+		s        settings.Settings
+	}{
+		{"guarded endpoint", prod, []string{"dir", ":/srv"}, guarded},
+		{"guarded host in the arguments", Endpoint{}, []string{"-o", "User=a:b", "dir", "root@10.0.0.7:/srv"}, guarded},
+		{"unparsed settings", stage, []string{"dir", ":/srv"}, settings.Parse([]byte("{"))},
+	}
+	for _, tt := range tests {
+		// the tests have no terminal to confirm on
+		if code, err := runTool(SCP, tt.endpoint, tt.args, Options{}, tt.s); err == nil || code != 1 {
+			t.Errorf("%s: runTool() = %d, %v, want a refusal", tt.name, code, err)
+		}
+	}
+	if _, err := os.Stat(log); err == nil {
+		t.Fatalf("scp ran for a guarded host")
+	}
+
+	if code, err := runTool(SCP, stage, []string{"dir", ":/srv"}, Options{}, guarded); err != nil || code != 0 {
+		t.Errorf("runTool() of an unguarded host = %d, %v", code, err)
+	}
+	if out, _ := os.ReadFile(log); string(out) != "scp dir deploy@10.1.0.5:/srv\n" {
+		t.Errorf("scp ran with %q", out)
+	}
+}
+
+func TestRunToolGuardOptions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	log := filepath.Join(dir, "log")
+	writeScript(t, dir, "rsync", "echo \"rsync $*\" >> "+log+"\n")
+	writeScript(t, dir, "scp", "echo \"scp $*\" >> "+log+"\n")
+
+	// confirm the guarded host as typed on a terminal
+	input := terminalInput
+	t.Cleanup(func() { terminalInput = input })
+	s := settings.Settings{Guards: []settings.Guard{{Match: []string{"10.0.0.*"}, NoAgentForwarding: true}}}
+	prod := Endpoint{SSHConfig: config.SSHConfig{Host: "10.0.0.5", User: "deploy"}}
+
+	tests := []struct {
+		tool     Tool
+		endpoint Endpoint
+		args     []string
+		typed    string
+		want     string
+	}{
+		{Rsync, prod, []string{"dir", ":/srv"}, "10.0.0.5\n", "rsync -e ssh -a dir deploy@10.0.0.5:/srv\n"},
+		{SCP, prod, []string{"dir", ":/srv"}, "10.0.0.5\n", "scp -o ForwardAgent=no dir deploy@10.0.0.5:/srv\n"},
+		{SCP, Endpoint{}, []string{"dir", "10.0.0.7:/srv"}, "10.0.0.7\n", "scp -o ForwardAgent=no dir 10.0.0.7:/srv\n"},
+	}
+	for _, tt := range tests {
+		os.Remove(log)
+		confirmation := bufio.NewReader(strings.NewReader(tt.typed))
+		terminalInput = func() *bufio.Reader { return confirmation }
+		if code, err := runTool(tt.tool, tt.endpoint, tt.args, Options{}, s); err != nil || code != 0 {
+			t.Fatalf("runTool(%s, %q) = %d, %v", tt.tool, tt.args, code, err)
+		}
+		if out, _ := os.ReadFile(log); string(out) != tt.want {
+			t.Errorf("%s ran with %q, want %q", tt.tool, out, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+const defaultBinary = "ssh"
+
+// Transport is the program run to connect to one host
+type Transport struct {
+	Binary string
+	Args   []string
+	Env    []string
+}
+
+// Command returns the command running the transport with args
+func (t Transport) Command(ctx context.Context, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, t.Binary, append(append([]string{}, t.Args...), args...)...)
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+	return cmd
+}
+
+// TransportFor returns the transport of the connection made with args,
+// taking the per host overrides of the settings into account
+func TransportFor(s settings.Transport, args []string) Transport {
+	binary, extra, env := s.Binary, s.Args, s.Env
+
+	if dest := Destination(args); dest != "" && len(s.Hosts) > 0 {
+		names := hostNames(dest)
+		for _, h := range s.Hosts {
+			if !matchesAny(h.Match, names) {
+				continue
+			}
+			if h.Binary != "" {
+				binary = h.Binary
+			}
+			if h.Args != nil {
+				extra = h.Args
+			}
+			env = mergeEnv(env, h.Env)
+			break
+		}
+	}
+
+	if binary == "" {
+		binary = defaultBinary
+	}
+	return Transport{Binary: binary, Args: extra, Env: envList(env)}
+}
+
+// CheckTransport makes sure every configured binary can be run, the error
+// names the setting to fix
+func CheckTransport(s settings.Transport) error {
+	binary := s.Binary
+	if binary == "" {
+		binary = defaultBinary
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		if s.Binary == "" {
+			return fmt.Errorf("ssh is not installed")
+		}
+		return fmt.Errorf("transport.binary %q in ~/.ggh/settings.json can't be run: %w", s.Binary, err)
+	}
+
+	for i, h := range s.Hosts {
+		if h.Binary == "" {
+			continue
+		}
+		if _, err := exec.LookPath(h.Binary); err != nil {
+			return fmt.Errorf("transport.hosts[%d].binary %q (match %q) in ~/.ggh/settings.json can't be run: %w", i, h.Binary, h.Match, err)
+		}
+	}
+	return nil
+}
+
+// hostNames returns dest and, for an alias, its HostName, the names
+// matched by the patterns of the settings
+func hostNames(dest string) []string {
+	names := []string{dest}
+	if c, err := config.GetConfig(dest); err == nil && c.Host != "" {
+		names = append(names, c.Host)
+	}
+	return names
+}
+
+func matchesAny(pattern string, names []string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, name := range names {
+		if ok, err := filepath.Match(pattern, strings.ToLower(name)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeEnv(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// envList returns the variables as KEY=value, sorted for stable commands
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
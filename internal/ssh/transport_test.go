@@ -0,0 +1,202 @@
+package ssh
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/config"
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestDestination(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"stage", "stage"},
+		{"root@10.0.0.1 -p 2222", "10.0.0.1"},
+		{"-p 2222 -i key root@10.0.0.1", "10.0.0.1"},
+		{"-p2222 -At root@10.0.0.1 uptime", "10.0.0.1"},
+		{"-L 8080:localhost:80 -o BatchMode=yes stage", "stage"},
+		{"-Ai key stage", "stage"},
+		{"ssh://deploy@web:22", "web:22"},
+		{"-- stage", "stage"},
+		{"-v", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Destination(strings.Fields(tt.args)); got != tt.want {
+			t.Errorf("Destination(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTransportFor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sshConfig := "Host db\n\tHostName db1.prod.example.com\n"
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := settings.Transport{
+		Binary: "/opt/ssh/bin/ssh",
+		Args:   []string{"-o", "LogLevel=ERROR"},
+		Env:    map[string]string{"AUDIT": "1"},
+		Hosts: []settings.HostTransport{
+			{Match: "*.prod.example.com", Binary: "/usr/local/bin/ssh-audit", Env: map[string]string{"TICKET": "ops"}},
+			{Match: "lab-*", Args: []string{}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		settings settings.Transport
+		args     []string
+		want     Transport
+	}{
+		{"default", settings.Transport{}, []string{"stage"}, Transport{Binary: "ssh", Env: []string{}}},
+		{"global", s, []string{"stage"}, Transport{Binary: "/opt/ssh/bin/ssh", Args: []string{"-o", "LogLevel=ERROR"}, Env: []string{"AUDIT=1"}}},
+		{"alias matched by HostName", s, []string{"db"}, Transport{Binary: "/usr/local/bin/ssh-audit", Args: []string{"-o", "LogLevel=ERROR"}, Env: []string{"AUDIT=1", "TICKET=ops"}}},
+		{"direct host", s, []string{"-p", "22", "root@LAB-1"}, Transport{Binary: "/opt/ssh/bin/ssh", Env: []string{"AUDIT=1"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TransportFor(tt.settings, tt.args)
+			if got.Binary != tt.want.Binary ||
+				strings.Join(got.Args, " ") != strings.Join(tt.want.Args, " ") ||
+				strings.Join(got.Env, " ") != strings.Join(tt.want.Env, " ") {
+				t.Errorf("TransportFor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTransport(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	wrapper := filepath.Join(dir, "ssh-wrapper")
+	if err := os.WriteFile(wrapper, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		settings settings.Transport
+		want     string
+	}{
+		{"no ssh", settings.Transport{}, "ssh is not installed"},
+		{"wrapper in PATH", settings.Transport{Binary: "ssh-wrapper"}, ""},
+		{"wrapper by path", settings.Transport{Binary: wrapper}, ""},
+		{"missing binary", settings.Transport{Binary: "/nope/ssh"}, `transport.binary "/nope/ssh"`},
+		{"missing host binary", settings.Transport{Binary: wrapper, Hosts: []settings.HostTransport{{Match: "prod-*", Binary: "nope"}}}, `transport.hosts[0].binary "nope" (match "prod-*")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTransport(tt.settings)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("CheckTransport() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CheckTransport() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransportCommand(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "ssh-wrapper")
+	script := "#!/bin/sh\necho \"$AUDIT $*\"\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := Transport{Binary: binary, Args: []string{"-o", "LogLevel=ERROR"}, Env: []string{"AUDIT=on"}}
+	out, err := transport.Command(context.Background(), []string{"stage", "uptime"}).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "on -o LogLevel=ERROR stage uptime" {
+		t.Errorf("transport ran with %q", got)
+	}
+}
+
+func TestToolTransport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ggh", "cm")
+	cm := "ControlPath=" + filepath.Join(dir, socketPrefix(dir, Connection{User: "deploy", Host: "10.0.0.5", Port: "2222"})+"%C")
+
+	s := settings.Settings{
+		Transport: settings.Transport{Binary: "/opt/ssh/bin/ssh", Args: []string{"-o", "LogLevel=ERROR"}},
+		Multiplex: settings.Multiplex{Hosts: []string{"10.0.0.*"}, Persist: "30s"},
+	}
+	mux := []string{"-o", "ControlMaster=auto", "-o", cm, "-o", "ControlPersist=30s"}
+	shared := Endpoint{SSHConfig: config.SSHConfig{Host: "10.0.0.5", User: "deploy", Port: "2222"}}
+	other := Endpoint{SSHConfig: config.SSHConfig{Host: "10.1.0.5", User: "deploy"}}
+
+	tests := []struct {
+		name     string
+		tool     Tool
+		endpoint Endpoint
+		args     []string
+		want     []string
+	}{
+		{"mosh", Mosh, shared, nil, []string{"--ssh=/opt/ssh/bin/ssh -o LogLevel=ERROR -o ControlMaster=auto -o " + cm + " -o ControlPersist=30s -p 2222", "deploy@10.0.0.5"}},
+		{"mosh without sharing", Mosh, other, nil, []string{"--ssh=/opt/ssh/bin/ssh -o LogLevel=ERROR", "deploy@10.1.0.5"}},
+		{"sftp", SFTP, shared, nil, append(append([]string{"-S", "/opt/ssh/bin/ssh", "-o", "LogLevel=ERROR"}, mux...), "-P", "2222", "deploy@10.0.0.5")},
+		{"scp", SCP, other, []string{"f", ":/srv"}, []string{"-S", "/opt/ssh/bin/ssh", "-o", "LogLevel=ERROR", "f", "deploy@10.1.0.5:/srv"}},
+		{"scp named hosts", SCP, Endpoint{}, []string{"a:/x", "."}, []string{"-S", "/opt/ssh/bin/ssh", "-o", "LogLevel=ERROR", "a:/x", "."}},
+		{"rsync", Rsync, other, []string{"-a", ":/srv", "."}, []string{"-e", "/opt/ssh/bin/ssh -o LogLevel=ERROR", "-a", "deploy@10.1.0.5:/srv", "."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToolArgs(tt.tool, tt.endpoint, tt.args, s)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ToolArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunToolHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	log := filepath.Join(dir, "log")
+	writeScript(t, dir, "sftp", "echo \"sftp $AUDIT $*\" >> "+log+"\nexit 4\n")
+	hook := writeScript(t, dir, "hook", "echo \"$GGH_HOOK $GGH_HOST $GGH_EXIT_STATUS\" >> "+log+"\n")
+
+	s := settings.Settings{
+		Transport: settings.Transport{Env: map[string]string{"AUDIT": "on"}},
+		Hooks: []settings.Hook{
+			{Match: "10.0.0.*", PreConnect: hook, PostDisconnect: hook},
+		},
+	}
+	endpoint := Endpoint{SSHConfig: config.SSHConfig{Host: "10.0.0.5", User: "deploy"}}
+	if code, err := runTool(SFTP, endpoint, nil, Options{}, s); err != nil || code != 4 {
+		t.Fatalf("runTool() = %d, %v, want 4", code, err)
+	}
+
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pre_connect 10.0.0.5 \nsftp on deploy@10.0.0.5\npost_disconnect 10.0.0.5 4\n"
+	if string(got) != want {
+		t.Errorf("ran\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,170 @@
+package theme
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// The IDs of the columns of the host selectors, as named in the settings
+const (
+	NameColumn      = "name"
+	HostColumn      = "host"
+	PortColumn      = "port"
+	UserColumn      = "user"
+	KeyColumn       = "key"
+	LastLoginColumn = "last_login"
+	CountColumn     = "count"
+	TunnelsColumn   = "tunnels"
+	ViaColumn       = "via"
+	TagsColumn      = "tags"
+	NoteColumn      = "note"
+	StatusColumn    = "status"
+)
+
+// hostColumn is a column of the host selectors, width is the base width
+// the extra space is shared on top of
+type hostColumn struct {
+	title   string
+	width   int
+	history bool // only the history has it
+}
+
+var hostColumns = map[string]hostColumn{
+	NameColumn:      {title: "Name", width: 10},
+	HostColumn:      {title: "Host", width: 20},
+	PortColumn:      {title: "Port", width: 5},
+	UserColumn:      {title: "User", width: 10},
+	KeyColumn:       {title: "Key", width: 0},
+	LastLoginColumn: {title: "Last login", width: 15, history: true},
+	CountColumn:     {title: "Count", width: 8, history: true},
+	TunnelsColumn:   {title: "Tunnels", width: 12, history: true},
+	ViaColumn:       {title: "Via", width: 12, history: true},
+	TagsColumn:      {title: "Tags", width: 12},
+	NoteColumn:      {title: "Note", width: 12},
+	StatusColumn:    {title: "Status", width: statusWidth},
+}
+
+// configWidths are the base widths the config selector gives its columns
+// instead, without the history columns there is room for more
+var configWidths = map[string]int{NameColumn: 15, KeyColumn: 10}
+
+// DefaultHostColumns returns the columns of a host selector when the
+// settings don't choose them. The status is added when probing.
+func DefaultHostColumns(what TableStyle) []string {
+	if what == HistoryTable {
+		return []string{NameColumn, HostColumn, PortColumn, UserColumn, KeyColumn, LastLoginColumn, TunnelsColumn, ViaColumn, TagsColumn, NoteColumn}
+	}
+	return []string{NameColumn, HostColumn, PortColumn, UserColumn, KeyColumn, TagsColumn, NoteColumn}
+}
+
+// HostColumnIDs returns the IDs of the columns a host selector can show
+func HostColumnIDs(what TableStyle) []string {
+	var ids []string
+	for id, c := range hostColumns {
+		if what == HistoryTable || !c.history {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// CheckHostColumn returns an error when the host selector can't show the
+// column id
+func CheckHostColumn(what TableStyle, id string) error {
+	c, ok := hostColumns[id]
+	if !ok {
+		return fmt.Errorf("unknown column %q (supported: %s)", id, strings.Join(HostColumnIDs(what), ", "))
+	}
+	if c.history && what != HistoryTable {
+		return fmt.Errorf("column %q is only in the history", id)
+	}
+	return nil
+}
+
+// HostColumnTitle returns the header of the column id
+func HostColumnTitle(id string) string {
+	return hostColumns[id].title
+}
+
+// AdjustHostColumns returns the size of a host selector showing the
+// columns ids, and the columns with their widths. The Key and then the
+// Name column get the extra space, the status keeps its width. Without
+// enough space all columns are scaled down.
+func AdjustHostColumns(what TableStyle, ids []string, windowWidth int, windowHeight int) (int, int, []table.Column) {
+	tableHeight := windowHeight - marginHeight
+	tableWidth := max(windowWidth-marginWidth, minTableWidth)
+	// Extra margin for content
+	widthForTableContent := tableWidth - contentExtraMargin
+
+	cols := make([]table.Column, len(ids))
+	baseWidths := make([]int, 0, len(ids))
+	shared := make([]int, 0, len(ids)) // the indices of the columns sharing
+	for i, id := range ids {
+		width := hostColumns[id].width
+		if w, ok := configWidths[id]; ok && what == ConfigTable {
+			width = w
+		}
+		cols[i] = table.Column{Title: hostColumns[id].title, Width: width}
+		if id == StatusColumn {
+			widthForTableContent -= width
+			continue
+		}
+		baseWidths = append(baseWidths, width)
+		shared = append(shared, i)
+	}
+
+	totalBase := 0
+	for _, w := range baseWidths {
+		totalBase += w
+	}
+
+	if widthForTableContent < totalBase {
+		ratio := float64(max(widthForTableContent, 0)) / float64(totalBase)
+		for j, i := range shared {
+			cols[i].Width = max(int(math.Round(float64(baseWidths[j])*ratio)), 1)
+		}
+		return tableWidth, tableHeight, cols
+	}
+
+	name := slices.Index(ids, NameColumn)
+	key := slices.Index(ids, KeyColumn)
+	// without one of them the other gets all the extra space
+	switch {
+	case name < 0 && key < 0 && len(shared) > 0:
+		name = shared[0]
+		key = shared[0]
+	case name < 0:
+		name = key
+	case key < 0:
+		key = name
+	}
+
+	leftover := widthForTableContent - totalBase
+	leftoverForKey := 0
+	leftoverForName := 0
+
+	for leftover > 0 {
+		if leftoverForKey < preferredKeyExtraWidth {
+			leftoverForKey++
+			leftover--
+		} else if leftoverForKey < maxKeyExtraWidth && leftover > 1 {
+			leftoverForName++
+			leftoverForKey++
+			leftover -= 2
+		} else {
+			leftoverForName++
+			leftover--
+		}
+	}
+
+	if name >= 0 {
+		cols[name].Width += leftoverForName
+		cols[key].Width += leftoverForKey
+	}
+	return tableWidth, tableHeight, cols
+}
@@ -0,0 +1,52 @@
+package theme
+
+import "testing"
+
+func TestAdjustHostColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		what   TableStyle
+		ids    []string
+		width  int
+		widths []int
+	}{
+		{"config defaults", ConfigTable, DefaultHostColumns(ConfigTable), 120, []int{18, 20, 5, 10, 28, 12, 12}},
+		{"reordered", ConfigTable, []string{KeyColumn, HostColumn, NameColumn}, 80, []int{27, 20, 18}},
+		{"without key the name gets the space", HistoryTable, []string{NameColumn, CountColumn}, 40, []int{17, 8}},
+		{"status keeps its width", HistoryTable, []string{NameColumn, HostColumn, StatusColumn}, 30, []int{2, 3, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, cols := AdjustHostColumns(tt.what, tt.ids, tt.width, 20)
+			if len(cols) != len(tt.widths) {
+				t.Fatalf("AdjustHostColumns() returned %d columns, want %d", len(cols), len(tt.widths))
+			}
+			for i, c := range cols {
+				if c.Title != HostColumnTitle(tt.ids[i]) || c.Width != tt.widths[i] {
+					t.Errorf("column %d = %s %d, want %s %d", i, c.Title, c.Width, HostColumnTitle(tt.ids[i]), tt.widths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckHostColumn(t *testing.T) {
+	tests := []struct {
+		what    TableStyle
+		id      string
+		wantErr bool
+	}{
+		{ConfigTable, NameColumn, false},
+		{ConfigTable, StatusColumn, false},
+		{HistoryTable, CountColumn, false},
+		{ConfigTable, LastLoginColumn, true},
+		{HistoryTable, "uptime", true},
+	}
+
+	for _, tt := range tests {
+		if err := CheckHostColumn(tt.what, tt.id); (err != nil) != tt.wantErr {
+			t.Errorf("CheckHostColumn(%d, %q) error = %v, wantErr %v", tt.what, tt.id, err, tt.wantErr)
+		}
+	}
+}
@@ -5,17 +5,108 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var BaseStyle = lipgloss.NewStyle().
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240"))
-
-var HeaderStyle = table.DefaultStyles().Header.
-	BorderStyle(lipgloss.NormalBorder()).
-	BorderForeground(lipgloss.Color("240")).
-	BorderBottom(true).
-	Bold(false)
-
-var SelectedStyle = table.DefaultStyles().Selected.
-	Foreground(lipgloss.Color("229")).
-	Background(lipgloss.Color("57")).
-	Bold(false)
+// The styles of the interface, set by Use from the theme
+var (
+	BaseStyle     lipgloss.Style
+	HeaderStyle   lipgloss.Style
+	SelectedStyle lipgloss.Style
+	// PrintHeaderStyle is the header of the tables printed by --config and
+	// the other listings
+	PrintHeaderStyle lipgloss.Style
+
+	// HelpStyle is the help bar of the lists
+	HelpStyle lipgloss.Style
+	// PromptStyle is the questions, prompts and filter text
+	PromptStyle lipgloss.Style
+	ErrorStyle  lipgloss.Style
+	// StrongStyle stands out from the help, like the command of a route
+	StrongStyle lipgloss.Style
+	// CountStyle is the counters of the lists, like the selected tunnels
+	CountStyle lipgloss.Style
+
+	// BannerStyle is the warning shown before connecting to a guarded host
+	BannerStyle lipgloss.Style
+
+	// The styles of the forms
+	FormStyle          lipgloss.Style
+	FormTitleStyle     lipgloss.Style
+	LabelStyle         lipgloss.Style
+	RequiredLabelStyle lipgloss.Style
+	InputStyle         lipgloss.Style
+	FocusedInputStyle  lipgloss.Style
+	PlaceholderStyle   lipgloss.Style
+	FormErrorStyle     lipgloss.Style
+	FormHelpStyle      lipgloss.Style
+)
+
+// Use sets the styles from the theme
+func Use(t Theme) {
+	c := func(pick func(Palette) string) lipgloss.TerminalColor {
+		if t.Mono {
+			return lipgloss.NoColor{}
+		}
+		return lipgloss.AdaptiveColor{Light: pick(t.Light), Dark: pick(t.Dark)}
+	}
+	border := c(func(p Palette) string { return p.Border })
+	title := c(func(p Palette) string { return p.Title })
+	muted := c(func(p Palette) string { return p.Muted })
+
+	BaseStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(border)
+
+	HeaderStyle = table.DefaultStyles().Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(border).
+		BorderBottom(true).
+		Bold(false)
+
+	SelectedStyle = table.DefaultStyles().Selected.
+		Foreground(c(func(p Palette) string { return p.Selected })).
+		Background(c(func(p Palette) string { return p.SelectedBackground })).
+		Bold(false)
+
+	PrintHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(title)
+
+	HelpStyle = lipgloss.NewStyle().Foreground(c(func(p Palette) string { return p.Help }))
+	PromptStyle = lipgloss.NewStyle().Foreground(c(func(p Palette) string { return p.Accent }))
+	ErrorStyle = lipgloss.NewStyle().Foreground(c(func(p Palette) string { return p.Error }))
+	StrongStyle = lipgloss.NewStyle().Bold(true)
+	CountStyle = lipgloss.NewStyle().Foreground(title)
+
+	BannerStyle = lipgloss.NewStyle().
+		Foreground(c(func(p Palette) string { return p.Banner })).
+		Background(c(func(p Palette) string { return p.BannerBackground })).
+		Bold(true).
+		Padding(0, 1)
+
+	FormStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(title).
+		Padding(1, 2)
+	FormTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(title).
+		Padding(1, 0)
+	LabelStyle = lipgloss.NewStyle().Foreground(c(func(p Palette) string { return p.Label }))
+	RequiredLabelStyle = lipgloss.NewStyle().Foreground(c(func(p Palette) string { return p.Required }))
+	InputStyle = lipgloss.NewStyle().Foreground(c(func(p Palette) string { return p.Text }))
+	FocusedInputStyle = lipgloss.NewStyle().Foreground(title).Bold(true)
+	PlaceholderStyle = lipgloss.NewStyle().Foreground(muted).Italic(true)
+	FormErrorStyle = ErrorStyle.Bold(true).Padding(1, 0)
+	FormHelpStyle = lipgloss.NewStyle().Foreground(muted).Padding(1, 0)
+
+	// without colors the attributes tell the parts apart
+	if t.Mono {
+		SelectedStyle = SelectedStyle.Reverse(true)
+		HelpStyle = HelpStyle.Faint(true)
+		PromptStyle = PromptStyle.Underline(true)
+		ErrorStyle = ErrorStyle.Bold(true)
+		CountStyle = CountStyle.Bold(true)
+		BannerStyle = BannerStyle.Reverse(true)
+		RequiredLabelStyle = RequiredLabelStyle.Bold(true)
+		FocusedInputStyle = FocusedInputStyle.Underline(true)
+		PlaceholderStyle = PlaceholderStyle.Faint(true)
+		FormHelpStyle = FormHelpStyle.Faint(true)
+	}
+}
@@ -14,6 +14,14 @@ const (
 	ConfigTable TableStyle = iota
 	HistoryTable
 	TunnelTable
+	ExecTable
+	DoctorTable
+	RecordingTable
+	MuxTable
+	RouteTable
+	KnownHostsTable
+	KeysTable
+	AgentTable
 )
 
 const (
@@ -25,6 +33,7 @@ const (
 	maxKeyExtraWidth       = 30
 	minTableHeight         = 3
 	maxTableHeight         = 8
+	statusWidth            = 10
 )
 
 func GetColumns(what TableStyle) []table.Column {
@@ -38,6 +47,8 @@ func GetColumns(what TableStyle) []table.Column {
 			{Title: "Port", Width: 10},
 			{Title: "User", Width: 10},
 			{Title: "Key", Width: 10},
+			{Title: "Tags", Width: 12},
+			{Title: "Note", Width: 12},
 		}...)
 	case HistoryTable:
 		columns = append(columns, []table.Column{
@@ -47,6 +58,10 @@ func GetColumns(what TableStyle) []table.Column {
 			{Title: "User", Width: 10},
 			{Title: "Key", Width: 10},
 			{Title: "Last login", Width: 15},
+			{Title: "Tunnels", Width: 12},
+			{Title: "Via", Width: 12},
+			{Title: "Tags", Width: 12},
+			{Title: "Note", Width: 12},
 		}...)
 	case TunnelTable:
 		columns = append(columns, []table.Column{
@@ -56,6 +71,73 @@ func GetColumns(what TableStyle) []table.Column {
 			{Title: "Remote", Width: 20},
 			{Title: "Description", Width: 25},
 		}...)
+	case ExecTable:
+		columns = append(columns, []table.Column{
+			{Title: "Host", Width: 20},
+			{Title: "Status", Width: 10},
+			{Title: "Exit", Width: 6},
+			{Title: "Duration", Width: 10},
+		}...)
+	case DoctorTable:
+		columns = append(columns, []table.Column{
+			{Title: "Name", Width: 15},
+			{Title: "Host", Width: 20},
+			{Title: "User", Width: 10},
+			{Title: "Port", Width: 5},
+			{Title: "Suggestion", Width: 15},
+			{Title: "Action", Width: 10},
+		}...)
+	case RecordingTable:
+		columns = append(columns, []table.Column{
+			{Title: "Name", Width: 35},
+			{Title: "Host", Width: 20},
+			{Title: "Start", Width: 20},
+			{Title: "Duration", Width: 10},
+			{Title: "Size", Width: 10},
+		}...)
+	case RouteTable:
+		columns = append(columns, []table.Column{
+			{Title: "Hop", Width: 5},
+			{Title: "Name", Width: 15},
+			{Title: "Host", Width: 20},
+			{Title: "Port", Width: 5},
+			{Title: "User", Width: 10},
+			{Title: "Key", Width: 10},
+		}...)
+	case KnownHostsTable:
+		columns = append(columns, []table.Column{
+			{Title: "Line", Width: 5},
+			{Title: "Hosts", Width: 30},
+			{Title: "Type", Width: 20},
+			{Title: "Fingerprint", Width: 25},
+			{Title: "Used by", Width: 20},
+		}...)
+	case KeysTable:
+		columns = append(columns, []table.Column{
+			{Title: "Key", Width: 25},
+			{Title: "Type", Width: 20},
+			{Title: "Bits", Width: 5},
+			{Title: "Fingerprint", Width: 25},
+			{Title: "Passphrase", Width: 10},
+			{Title: "Mode", Width: 5},
+			{Title: "Hosts", Width: 20},
+		}...)
+	case AgentTable:
+		columns = append(columns, []table.Column{
+			{Title: "Type", Width: 20},
+			{Title: "Fingerprint", Width: 25},
+			{Title: "Comment", Width: 20},
+			{Title: "Key", Width: 25},
+			{Title: "Hosts", Width: 20},
+		}...)
+	case MuxTable:
+		columns = append(columns, []table.Column{
+			{Title: "Host", Width: 25},
+			{Title: "User", Width: 10},
+			{Title: "Port", Width: 5},
+			{Title: "Age", Width: 10},
+			{Title: "Status", Width: 10},
+		}...)
 	}
 
 	return columns
@@ -69,7 +151,7 @@ func PrintTable(rows []table.Row, p TableStyle) string {
 		table.WithRows(rows),
 		table.WithFocused(false),
 		table.WithStyles(table.Styles{
-			Header:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212")),
+			Header:   PrintHeaderStyle,
 			Selected: lipgloss.NewStyle(),
 		}),
 		table.WithHeight(len(rows)+1),
@@ -93,96 +175,19 @@ func GetTableHeight(tableHeight int, filteredRowsLen int) int {
 	return int(math.Max(minTableHeight, expected))
 }
 
+// AdjustTableDimensions returns the size of a table and its columns with
+// their widths. The host selectors use AdjustHostColumns.
 func AdjustTableDimensions(cols []table.Column, windowWidth int, windowHeight int) (int, int, []table.Column) {
 	tableHeight := windowHeight - marginHeight
 	tableWidth := max(windowWidth-marginWidth, minTableWidth)
 	// Extra margin for content
 	widthForTableContent := tableWidth - contentExtraMargin
 
-	switch len(cols) {
-	// SELECT CONFIG
-	case 5:
-		// columns = [Name, Host, Port, User, Key]
-		// base widths = 15,20,5,10,10 = total 60
-		baseWidths := []int{15, 20, 5, 10, 10}
-		const totalBase = 60
-
-		if widthForTableContent >= totalBase {
-			leftover := widthForTableContent - totalBase
-			leftoverForKey := 0
-			leftoverForName := 0
-
-			for leftover > 0 {
-				if leftoverForKey < preferredKeyExtraWidth {
-					leftoverForKey++
-					leftover--
-				} else if leftoverForKey < maxKeyExtraWidth && leftover > 1 {
-					leftoverForName++
-					leftoverForKey++
-					leftover -= 2
-				} else {
-					leftoverForName++
-					leftover--
-				}
-			}
-
-			cols[0].Width = baseWidths[0] + leftoverForName // Name
-			cols[1].Width = baseWidths[1]                   // Host
-			cols[2].Width = baseWidths[2]                   // Port
-			cols[3].Width = baseWidths[3]                   // User
-			cols[4].Width = baseWidths[4] + leftoverForKey  // Key
-		} else {
-			// Scale all columns proportionally
-			ratio := float64(widthForTableContent) / float64(totalBase)
-			for i := range cols {
-				w := max(int(math.Round(float64(baseWidths[i])*ratio)), 1)
-				cols[i].Width = w
-			}
+	// Keep the widths of the doctor table fixed
+	if len(cols) == 6 && cols[4].Title == "Suggestion" {
+		for i, w := range []int{15, 20, 10, 5, 15, 10} {
+			cols[i].Width = w
 		}
-
-	// SELECT HISTORY
-	case 6:
-		// columns = [Name,Host,Port,User,Key,Last login]
-		// base widths = 10,20,5,10,0,15 = total 60
-		baseWidths := []int{10, 20, 5, 10, 0, 15}
-		const totalBase = 60
-
-		if widthForTableContent >= totalBase {
-			leftover := widthForTableContent - totalBase
-			leftoverForKey := 0
-			leftoverForName := 0
-
-			for leftover > 0 {
-				if leftoverForKey < preferredKeyExtraWidth {
-					leftoverForKey++
-					leftover--
-				} else if leftoverForKey < maxKeyExtraWidth && leftover > 1 {
-					leftoverForName++
-					leftoverForKey++
-					leftover -= 2
-				} else {
-					leftoverForName++
-					leftover--
-				}
-			}
-
-			cols[0].Width = baseWidths[0] + leftoverForName // Name
-			cols[1].Width = baseWidths[1]                   // Host
-			cols[2].Width = baseWidths[2]                   // Port
-			cols[3].Width = baseWidths[3]                   // User
-			cols[4].Width = baseWidths[4] + leftoverForKey  // Key
-			cols[5].Width = baseWidths[5]                   // Last login
-		} else {
-			// Not enough space → scale all columns proportionally
-			ratio := float64(widthForTableContent) / float64(totalBase)
-			for i := range cols {
-				w := max(int(math.Round(float64(baseWidths[i])*ratio)), 1)
-				cols[i].Width = w
-			}
-		}
-
-		// TUNNEL TABLE (case 5 columns, but different from ConfigTable)
-		// We need to handle this after config table
 	}
 
 	// Special handling for tunnel table by checking column titles
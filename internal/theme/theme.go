@@ -0,0 +1,193 @@
+package theme
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+)
+
+// Palette is the colors of the interface, as ANSI numbers like "57" or hex
+// colors like "#FF0060". An empty color is the terminal's own.
+type Palette struct {
+	// Border is the border of the tables
+	Border string
+	// Selected and SelectedBackground are the selected row
+	Selected           string
+	SelectedBackground string
+	// Accent is the prompts and the filter text
+	Accent string
+	// Help is the help bar of the lists
+	Help  string
+	Error string
+	// Title is the titles and borders of the forms and the printed headers
+	Title string
+	// Label is the form labels, Required the labels of required fields
+	Label    string
+	Required string
+	// Text is the values typed in the forms
+	Text string
+	// Muted is the placeholders and the help of the forms
+	Muted string
+	// Banner and BannerBackground are the warning of guarded hosts
+	Banner           string
+	BannerBackground string
+}
+
+// set changes the color of role, as named in the settings
+func (p *Palette) set(role, color string) error {
+	fields := map[string]*string{
+		"border":              &p.Border,
+		"selected":            &p.Selected,
+		"selected_background": &p.SelectedBackground,
+		"accent":              &p.Accent,
+		"help":                &p.Help,
+		"error":               &p.Error,
+		"title":               &p.Title,
+		"label":               &p.Label,
+		"required":            &p.Required,
+		"text":                &p.Text,
+		"muted":               &p.Muted,
+		"banner":              &p.Banner,
+		"banner_background":   &p.BannerBackground,
+	}
+	field, ok := fields[role]
+	if !ok {
+		return fmt.Errorf("unknown theme color %q", role)
+	}
+	*field = color
+	return nil
+}
+
+// Theme is a palette for dark terminals and one for light terminals
+type Theme struct {
+	Dark  Palette
+	Light Palette
+	// Mono draws with bold, underline and reverse video instead of colors
+	Mono bool
+}
+
+var dark = Palette{
+	Border:             "240",
+	Selected:           "229",
+	SelectedBackground: "57",
+	Accent:             "57",
+	Help:               "#4A4A4A",
+	Error:              "#FF79C6",
+	Title:              "212",
+	Label:              "240",
+	Required:           "205",
+	Text:               "255",
+	Muted:              "240",
+	Banner:             "231",
+	BannerBackground:   "160",
+}
+
+var light = Palette{
+	Border:             "240",
+	Selected:           "229",
+	SelectedBackground: "57",
+	Accent:             "57",
+	Help:               "#B2B2B2",
+	Error:              "#FF0060",
+	Title:              "162",
+	Label:              "243",
+	Required:           "162",
+	Text:               "235",
+	Muted:              "246",
+	Banner:             "231",
+	BannerBackground:   "160",
+}
+
+var highContrast = Palette{
+	Border:             "15",
+	Selected:           "0",
+	SelectedBackground: "11",
+	Accent:             "14",
+	Help:               "7",
+	Error:              "9",
+	Title:              "11",
+	Label:              "7",
+	Required:           "14",
+	Text:               "15",
+	Muted:              "7",
+	Banner:             "15",
+	BannerBackground:   "9",
+}
+
+// Themes are the built-in themes. auto follows the background of the
+// terminal.
+var Themes = map[string]Theme{
+	"auto":          {Dark: dark, Light: light},
+	"dark":          {Dark: dark, Light: dark},
+	"light":         {Dark: light, Light: light},
+	"high-contrast": {Dark: highContrast, Light: highContrast},
+	"monochrome":    {Mono: true},
+}
+
+// Names returns the names of the built-in themes, sorted
+func Names() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the theme named name, built-in or from the settings.
+// NO_COLOR makes every theme monochrome.
+func Resolve(s settings.Settings) (Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return Themes["monochrome"], nil
+	}
+
+	name := s.Theme
+	if name == "" {
+		name = "auto"
+	}
+	if t, ok := Themes[name]; ok {
+		return t, nil
+	}
+
+	user, ok := s.Themes[name]
+	if !ok {
+		return Themes["auto"], fmt.Errorf("unknown theme %q", name)
+	}
+	base := user.Base
+	if base == "" {
+		base = "auto"
+	}
+	t, ok := Themes[base]
+	if !ok {
+		return Themes["auto"], fmt.Errorf("theme %q: unknown base theme %q", name, base)
+	}
+	for role, color := range user.Colors {
+		if err := t.Dark.set(role, color); err != nil {
+			return Themes["auto"], fmt.Errorf("theme %q: %w", name, err)
+		}
+		_ = t.Light.set(role, color)
+	}
+	return t, nil
+}
+
+// Load applies the theme of the settings, the auto theme when it can't be
+// resolved
+func Load(s settings.Settings) error {
+	t, err := Resolve(s)
+	// lipgloss drops every attribute under NO_COLOR, monochrome keeps bold,
+	// underline and reverse video so the selection stays visible
+	if t.Mono && os.Getenv("NO_COLOR") != "" && isatty.IsTerminal(os.Stdout.Fd()) {
+		lipgloss.SetColorProfile(termenv.ANSI)
+	}
+	Use(t)
+	return err
+}
+
+func init() {
+	Use(Themes["auto"])
+}
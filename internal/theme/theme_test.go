@@ -0,0 +1,68 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MrLonely14/ggh/internal/settings"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	user := map[string]settings.UserTheme{
+		"ocean":  {Base: "dark", Colors: map[string]string{"accent": "#0087FF", "selected_background": "24"}},
+		"plain":  {Colors: map[string]string{"error": "9"}},
+		"broken": {Colors: map[string]string{"sparkle": "1"}},
+		"orphan": {Base: "solarized"},
+	}
+
+	tests := []struct {
+		theme   string
+		check   func(Theme) bool
+		wantErr string
+	}{
+		{"", func(th Theme) bool { return th.Dark.Help == dark.Help && th.Light.Help == light.Help }, ""},
+		{"light", func(th Theme) bool { return th.Dark == light && th.Light == light }, ""},
+		{"high-contrast", func(th Theme) bool { return th.Dark.SelectedBackground == "11" }, ""},
+		{"monochrome", func(th Theme) bool { return th.Mono }, ""},
+		{"ocean", func(th Theme) bool {
+			return th.Dark.Accent == "#0087FF" && th.Light.Accent == "#0087FF" &&
+				th.Dark.SelectedBackground == "24" && th.Dark.Error == dark.Error
+		}, ""},
+		{"plain", func(th Theme) bool { return th.Dark.Error == "9" && th.Light.Help == light.Help }, ""},
+		{"nope", nil, `unknown theme "nope"`},
+		{"broken", nil, `unknown theme color "sparkle"`},
+		{"orphan", nil, `unknown base theme "solarized"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.theme, func(t *testing.T) {
+			th, err := Resolve(settings.Settings{Theme: tt.theme, Themes: user})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %q", tt.theme, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.theme, err)
+			}
+			if !tt.check(th) {
+				t.Errorf("Resolve(%q) = %+v", tt.theme, th)
+			}
+		})
+	}
+
+	// the built-in themes are never overridden by user themes
+	if th, _ := Resolve(settings.Settings{Theme: "dark", Themes: map[string]settings.UserTheme{"dark": {Colors: map[string]string{"accent": "1"}}}}); th.Dark.Accent != dark.Accent {
+		t.Errorf("a user theme named dark replaced the built-in one")
+	}
+}
+
+func TestResolveNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	th, err := Resolve(settings.Settings{Theme: "high-contrast"})
+	if err != nil || !th.Mono {
+		t.Errorf("Resolve() with NO_COLOR = %+v, %v, want monochrome", th, err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/MrLonely14/ggh/internal/output"
 )
 
 // TunnelType represents the type of SSH tunnel
@@ -190,3 +192,34 @@ func ParseSSHFlag(flag string) (*Tunnel, error) {
 	// Validation should be done separately when creating/updating tunnels
 	return tunnel, nil
 }
+
+// IDs returns the IDs of the tunnels
+func IDs(tunnels []Tunnel) []string {
+	ids := make([]string, len(tunnels))
+	for i, t := range tunnels {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// RecordKeys are the fields of a tunnel in machine readable output
+var RecordKeys = []string{"id", "name", "type", "local_port", "remote_host", "remote_port", "bind_address", "description", "last_used"}
+
+// Records converts tunnels to output records
+func Records(tunnels []Tunnel) []output.Record {
+	records := make([]output.Record, 0, len(tunnels))
+	for _, t := range tunnels {
+		records = append(records, output.Record{
+			{Key: "id", Value: t.ID},
+			{Key: "name", Value: t.Name},
+			{Key: "type", Value: string(t.Type)},
+			{Key: "local_port", Value: t.LocalPort},
+			{Key: "remote_host", Value: t.RemoteHost},
+			{Key: "remote_port", Value: t.RemotePort},
+			{Key: "bind_address", Value: t.BindAddress},
+			{Key: "description", Value: t.Description},
+			{Key: "last_used", Value: t.LastUsed},
+		})
+	}
+	return records
+}